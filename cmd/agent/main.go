@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
+	"time"
 
 	"github.com/1Asi1/metric-track.git/internal/agent/config"
 	"github.com/1Asi1/metric-track.git/internal/agent/integration"
 	"github.com/1Asi1/metric-track.git/internal/agent/service"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
 	"github.com/1Asi1/metric-track.git/internal/logger"
 	"github.com/rs/zerolog"
 )
@@ -35,20 +38,39 @@ func main() {
 	l := logger.NewLogger()
 	l = l.Level(zerolog.InfoLevel).With().Timestamp().Logger()
 
-	s := service.New(cfg, l)
-	c := integration.New(cfg, s, l)
+	tel := telemetry.New()
+	s := service.New(cfg, tel, l)
+	c := integration.New(cfg, s, tel, l)
+
+	var debug *http.Server
+	if cfg.DebugAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/metrics", tel.Handler())
+		debug = &http.Server{Addr: cfg.DebugAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+		go func() {
+			if err := debug.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.Err(err).Msg("debug.ListenAndServe")
+			}
+		}()
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	wg := &sync.WaitGroup{}
-	defer func() {
-		wg.Wait()
-	}()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer l.Info().Msg("agent has been shutdown")
-		<-ctx.Done()
-	}()
-	c.SendMetricPeriodic(ctx)
+
+	c.Start(context.Background())
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err = c.Stop(shutdownCtx); err != nil {
+		l.Err(err).Msg("c.Stop")
+	}
+	if debug != nil {
+		if err = debug.Shutdown(shutdownCtx); err != nil {
+			l.Err(err).Msg("debug.Shutdown")
+		}
+	}
+	l.Info().Msg("agent has been shutdown")
 }
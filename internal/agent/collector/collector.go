@@ -0,0 +1,361 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrDuplicate       = errors.New("collector already registered")
+	ErrNotRegistered   = errors.New("collector not registered")
+	ErrInvalidInterval = errors.New("collector poll interval must be positive")
+)
+
+// Collector источник метрик агента.
+type Collector interface {
+	// Name уникальное имя коллектора, по нему коллектор настраивается в конфигурации.
+	Name() string
+	// Collect снимает текущие значения метрик.
+	Collect(ctx context.Context) ([]metric.Metric, error)
+}
+
+// Observer получает время и результат каждого опроса коллектора.
+type Observer interface {
+	ObserveCollect(name string, d time.Duration, err error)
+}
+
+// Settings настройки опроса коллектора.
+type Settings struct {
+	PollInterval time.Duration
+	// отправлять минимум, максимум, среднее и число значений gauge-метрик за интервал отправки
+	// отдельными метриками с суффиксами Min, Max, Mean и Count.
+	Aggregate bool
+	// выключенный коллектор не опрашивается и не возвращает метрик, пока его не включат.
+	Disabled bool
+}
+
+// Registry набор коллекторов, каждый из которых опрашивается со своим интервалом.
+// Registry хранит последние значения gauge-метрик каждого коллектора
+// и сумму приращений counter-метрик с момента последнего чтения.
+type Registry struct {
+	log      zerolog.Logger
+	observer Observer
+	// коллектор, успешные опросы которого считаются в polls.
+	pollsOf string
+	polls   atomic.Int64
+
+	mu      sync.RWMutex
+	entries []*entry
+}
+
+type entry struct {
+	collector Collector
+	settings  Settings
+	// сигнал опросу об изменении настроек.
+	reset    chan struct{}
+	gauges   []metric.Metric
+	counters []metric.Metric
+	// агрегаты gauge-метрик за текущий интервал отправки в порядке появления.
+	windows []*window
+}
+
+// window агрегат значений одной gauge-метрики.
+type window struct {
+	last  metric.Metric
+	min   float64
+	max   float64
+	sum   float64
+	count int
+}
+
+func NewRegistry(log zerolog.Logger) *Registry {
+	return &Registry{log: log}
+}
+
+// Observe подключает наблюдателя за опросами коллекторов, вызывается до Start.
+func (r *Registry) Observe(o Observer) {
+	r.observer = o
+}
+
+// CountPolls задаёт коллектор, успешные опросы которого возвращает Polls, вызывается до Start.
+func (r *Registry) CountPolls(name string) {
+	r.pollsOf = name
+}
+
+// Polls возвращает число успешных опросов коллектора из CountPolls с прошлого вызова и обнуляет его,
+// каждый опрос учитывается ровно один раз.
+func (r *Registry) Polls() int64 {
+	return r.polls.Swap(0)
+}
+
+// Register добавляет коллектор с настройками опроса settings.
+func (r *Registry) Register(c Collector, settings Settings) error {
+	if settings.PollInterval <= 0 {
+		return fmt.Errorf("%s: %w", c.Name(), ErrInvalidInterval)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range r.entries {
+		if v.collector.Name() == c.Name() {
+			return fmt.Errorf("%s: %w", c.Name(), ErrDuplicate)
+		}
+	}
+
+	r.entries = append(r.entries, &entry{collector: c, settings: settings, reset: make(chan struct{}, 1)})
+
+	return nil
+}
+
+// Configure заменяет настройки опроса коллектора name, в том числе после Start.
+// Новый интервал действует сразу, выключенный коллектор перестаёт возвращать метрики,
+// а включённый снова опрашивается немедленно.
+func (r *Registry) Configure(name string, settings Settings) error {
+	if settings.PollInterval <= 0 {
+		return fmt.Errorf("%s: %w", name, ErrInvalidInterval)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range r.entries {
+		if v.collector.Name() != name {
+			continue
+		}
+		if v.settings == settings {
+			return nil
+		}
+
+		if settings.Disabled || !settings.Aggregate {
+			v.windows = nil
+		}
+		if settings.Disabled {
+			v.gauges = nil
+			v.counters = nil
+		}
+		v.settings = settings
+
+		select {
+		case v.reset <- struct{}{}:
+		default:
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", name, ErrNotRegistered)
+}
+
+// Names возвращает имена включённых коллекторов в порядке регистрации.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]string, 0, len(r.entries))
+	for _, v := range r.entries {
+		if v.settings.Disabled {
+			continue
+		}
+		res = append(res, v.collector.Name())
+	}
+
+	return res
+}
+
+// All возвращает имена всех коллекторов, включая выключенные, в порядке регистрации.
+func (r *Registry) All() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]string, 0, len(r.entries))
+	for _, v := range r.entries {
+		res = append(res, v.collector.Name())
+	}
+
+	return res
+}
+
+// Start опрашивает все коллекторы один раз и запускает их периодический опрос до отмены ctx.
+func (r *Registry) Start(ctx context.Context) {
+	r.mu.RLock()
+	entries := append([]*entry(nil), r.entries...)
+	r.mu.RUnlock()
+
+	for _, v := range entries {
+		if !r.settings(v).Disabled {
+			r.collect(ctx, v)
+		}
+	}
+
+	for _, v := range entries {
+		go r.poll(ctx, v)
+	}
+}
+
+// Metrics возвращает последние значения gauge-метрик и накопленные приращения counter-метрик,
+// а для коллекторов с агрегацией - ещё и агрегаты gauge-метрик за интервал отправки.
+// Накопленные приращения и агрегаты сбрасываются, поэтому каждое значение возвращается ровно один раз.
+func (r *Registry) Metrics() []metric.Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []metric.Metric
+	for _, v := range r.entries {
+		res = append(res, v.gauges...)
+		res = append(res, v.counters...)
+		v.counters = nil
+
+		for _, w := range v.windows {
+			res = append(res, w.metrics()...)
+		}
+		v.windows = nil
+	}
+
+	return res
+}
+
+func (r *Registry) poll(ctx context.Context, e *entry) {
+	settings := r.settings(e)
+	ticker := time.NewTicker(settings.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.reset:
+			prev := settings
+			settings = r.settings(e)
+			ticker.Reset(settings.PollInterval)
+			if prev.Disabled && !settings.Disabled {
+				r.collect(ctx, e)
+			}
+		case <-ticker.C:
+			if !settings.Disabled {
+				r.collect(ctx, e)
+			}
+		}
+	}
+}
+
+func (r *Registry) settings(e *entry) Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return e.settings
+}
+
+// collect опрашивает коллектор, при ошибке сохраняются предыдущие значения.
+func (r *Registry) collect(ctx context.Context, e *entry) {
+	start := time.Now()
+	metrics, err := e.collector.Collect(ctx)
+	if r.observer != nil {
+		r.observer.ObserveCollect(e.collector.Name(), time.Since(start), err)
+	}
+	if err != nil {
+		r.log.Err(err).Msgf("collector: %s", e.collector.Name())
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// коллектор могли выключить, пока шёл опрос.
+	if e.settings.Disabled {
+		return
+	}
+	if e.collector.Name() == r.pollsOf {
+		r.polls.Add(1)
+	}
+
+	gauges := make([]metric.Metric, 0, len(metrics))
+	var reserved int
+	for _, v := range metrics {
+		// имена с префиксом телеметрии зарезервированы за агентом, метрики скриптов
+		// и prometheus с такими именами перезаписали бы его собственные метрики.
+		if e.collector.Name() != telemetry.CollectorName && strings.HasPrefix(v.Name, telemetry.Prefix) {
+			reserved++
+			continue
+		}
+
+		if v.Kind != metric.KindCounter {
+			gauges = append(gauges, v)
+			continue
+		}
+
+		e.counters = addCounter(e.counters, v)
+	}
+	e.gauges = gauges
+
+	if reserved != 0 {
+		r.log.Warn().Msgf("collector: %s, dropped %d metrics with reserved prefix %s",
+			e.collector.Name(), reserved, telemetry.Prefix)
+	}
+
+	if e.settings.Aggregate {
+		for _, v := range gauges {
+			e.windows = addSample(e.windows, v)
+		}
+	}
+}
+
+// addCounter прибавляет приращение к накопленному значению счётчика с тем же ID.
+func addCounter(counters []metric.Metric, m metric.Metric) []metric.Metric {
+	for i, v := range counters {
+		if v.ID() == m.ID() {
+			counters[i].Delta += m.Delta
+			counters[i].Timestamp = m.Timestamp
+			return counters
+		}
+	}
+
+	return append(counters, m)
+}
+
+// addSample добавляет значение gauge-метрики в агрегат с тем же ID.
+func addSample(windows []*window, m metric.Metric) []*window {
+	for _, v := range windows {
+		if v.last.ID() == m.ID() {
+			v.last = m
+			v.min = math.Min(v.min, m.Value)
+			v.max = math.Max(v.max, m.Value)
+			v.sum += m.Value
+			v.count++
+			return windows
+		}
+	}
+
+	return append(windows, &window{last: m, min: m.Value, max: m.Value, sum: m.Value, count: 1})
+}
+
+// metrics возвращает агрегаты как отдельные gauge-метрики с теми же метками.
+func (w *window) metrics() []metric.Metric {
+	res := make([]metric.Metric, 0, 4)
+	for _, v := range []struct {
+		suffix string
+		value  float64
+	}{
+		{suffix: "Min", value: w.min},
+		{suffix: "Max", value: w.max},
+		{suffix: "Mean", value: w.sum / float64(w.count)},
+		{suffix: "Count", value: float64(w.count)},
+	} {
+		m := w.last
+		m.Name += v.suffix
+		m.Value = v.value
+		res = append(res, m)
+	}
+
+	return res
+}
@@ -0,0 +1,328 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogger() zerolog.Logger {
+	out := zerolog.ConsoleWriter{
+		Out:        os.Stderr,
+		TimeFormat: "2006-01-02 15:04:05 -0700",
+		NoColor:    true,
+	}
+
+	l := zerolog.New(out)
+
+	return l.Level(zerolog.InfoLevel).With().Timestamp().Logger()
+}
+
+type fakeCollector struct {
+	name  string
+	kind  metric.Kind
+	calls atomic.Int64
+	err   error
+}
+
+func (f *fakeCollector) Name() string {
+	return f.name
+}
+
+func (f *fakeCollector) Collect(_ context.Context) ([]metric.Metric, error) {
+	n := f.calls.Add(1)
+	if f.err != nil && n > 1 {
+		return nil, f.err
+	}
+
+	if f.kind == metric.KindCounter {
+		return []metric.Metric{metric.NewCounter(f.name, n)}, nil
+	}
+
+	return []metric.Metric{metric.NewGauge(f.name, float64(n))}, nil
+}
+
+func TestRegistry_Register(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		wantErr  error
+	}{
+		{
+			name:     "positive",
+			interval: time.Second,
+		},
+		{
+			name:     "zero interval",
+			interval: 0,
+			wantErr:  ErrInvalidInterval,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(newLogger())
+			err := r.Register(&fakeCollector{name: "fake"}, Settings{PollInterval: tt.interval})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	r := NewRegistry(newLogger())
+	require.NoError(t, r.Register(&fakeCollector{name: "fake"}, Settings{PollInterval: time.Second}))
+	assert.ErrorIs(t, r.Register(&fakeCollector{name: "fake"}, Settings{PollInterval: time.Second}), ErrDuplicate)
+	assert.Equal(t, []string{"fake"}, r.Names())
+}
+
+func TestRegistry_Intervals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fast := &fakeCollector{name: "fast"}
+	slow := &fakeCollector{name: "slow"}
+
+	r := NewRegistry(newLogger())
+	require.NoError(t, r.Register(fast, Settings{PollInterval: 10 * time.Millisecond}))
+	require.NoError(t, r.Register(slow, Settings{PollInterval: time.Hour}))
+
+	r.Start(ctx)
+	assert.Len(t, r.Metrics(), 2)
+
+	assert.Eventually(t, func() bool { return fast.calls.Load() > 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(1), slow.calls.Load())
+
+	got := make(map[string]float64)
+	for _, v := range r.Metrics() {
+		got[v.Name] = v.Value
+	}
+	assert.Greater(t, got["fast"], 1.0)
+	assert.Equal(t, 1.0, got["slow"])
+}
+
+func TestRegistry_Polls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	counted := &fakeCollector{name: "runtime"}
+	other := &fakeCollector{name: "other"}
+
+	r := NewRegistry(newLogger())
+	r.CountPolls("runtime")
+	require.NoError(t, r.Register(counted, Settings{PollInterval: time.Millisecond}))
+	require.NoError(t, r.Register(other, Settings{PollInterval: time.Millisecond}))
+	r.Start(ctx)
+
+	// опросы читаются параллельно с их учётом, каждый опрос учитывается ровно один раз.
+	var sum int64
+	for counted.calls.Load() < 50 {
+		sum += r.Polls()
+	}
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		sum += r.Polls()
+		return sum == counted.calls.Load()
+	}, time.Second, 5*time.Millisecond)
+
+	// неудачные опросы не учитываются.
+	failing := &fakeCollector{name: "runtime", err: errors.New("collect failed")}
+	r = NewRegistry(newLogger())
+	r.CountPolls("runtime")
+	require.NoError(t, r.Register(failing, Settings{PollInterval: time.Millisecond}))
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	r.Start(ctx)
+
+	assert.Eventually(t, func() bool { return failing.calls.Load() > 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), r.Polls())
+}
+
+func TestRegistry_Configure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeCollector{name: "fake"}
+	off := &fakeCollector{name: "off"}
+
+	r := NewRegistry(newLogger())
+	require.NoError(t, r.Register(c, Settings{PollInterval: time.Hour}))
+	require.NoError(t, r.Register(off, Settings{PollInterval: time.Hour, Disabled: true}))
+	assert.Equal(t, []string{"fake"}, r.Names())
+
+	r.Start(ctx)
+	assert.Equal(t, int64(1), c.calls.Load())
+	assert.Equal(t, int64(0), off.calls.Load())
+
+	// новый интервал применяется без перезапуска.
+	require.NoError(t, r.Configure("fake", Settings{PollInterval: 10 * time.Millisecond}))
+	assert.Eventually(t, func() bool { return c.calls.Load() > 3 }, time.Second, 5*time.Millisecond)
+
+	// включённый коллектор опрашивается сразу.
+	require.NoError(t, r.Configure("off", Settings{PollInterval: time.Hour}))
+	assert.Eventually(t, func() bool { return off.calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"fake", "off"}, r.Names())
+
+	// выключенный коллектор больше не возвращает метрик.
+	require.NoError(t, r.Configure("fake", Settings{PollInterval: 10 * time.Millisecond, Disabled: true}))
+	calls := c.calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, c.calls.Load(), calls+1)
+	for _, v := range r.Metrics() {
+		assert.Equal(t, "off", v.Name)
+	}
+
+	assert.ErrorIs(t, r.Configure("missing", Settings{PollInterval: time.Second}), ErrNotRegistered)
+	assert.ErrorIs(t, r.Configure("fake", Settings{}), ErrInvalidInterval)
+}
+
+func TestRegistry_KeepsLastOnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeCollector{name: "broken", err: errors.New("collect failed")}
+
+	r := NewRegistry(newLogger())
+	require.NoError(t, r.Register(c, Settings{PollInterval: 10 * time.Millisecond}))
+
+	r.Start(ctx)
+	assert.Eventually(t, func() bool { return c.calls.Load() > 2 }, time.Second, 5*time.Millisecond)
+
+	metrics := r.Metrics()
+	require.Len(t, metrics, 1)
+	assert.Equal(t, 1.0, metrics[0].Value)
+}
+
+func TestRegistry_AccumulatesCounters(t *testing.T) {
+	c := &fakeCollector{name: "requests", kind: metric.KindCounter}
+
+	r := NewRegistry(newLogger())
+	require.NoError(t, r.Register(c, Settings{PollInterval: time.Hour}))
+
+	e := r.entries[0]
+	r.collect(context.Background(), e)
+	r.collect(context.Background(), e)
+	r.collect(context.Background(), e)
+
+	metrics := r.Metrics()
+	require.Len(t, metrics, 1)
+	assert.Equal(t, int64(1+2+3), metrics[0].Delta)
+
+	assert.Empty(t, r.Metrics())
+
+	r.collect(context.Background(), e)
+	metrics = r.Metrics()
+	require.Len(t, metrics, 1)
+	assert.Equal(t, int64(4), metrics[0].Delta)
+}
+
+// namedCollector возвращает метрики с заданными именами.
+type namedCollector struct {
+	name    string
+	metrics []metric.Metric
+}
+
+func (n namedCollector) Name() string {
+	return n.name
+}
+
+func (n namedCollector) Collect(_ context.Context) ([]metric.Metric, error) {
+	return n.metrics, nil
+}
+
+func TestRegistry_ReservedPrefix(t *testing.T) {
+	metrics := []metric.Metric{
+		metric.NewGauge("AgentUptime", 1),
+		metric.NewCounter("AgentSendErrors", 5),
+		metric.NewGauge("QueueLength", 3),
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "exec", want: []string{"QueueLength"}},
+		{name: "prometheus", want: []string{"QueueLength"}},
+		{name: telemetry.CollectorName, want: []string{"AgentUptime", "QueueLength", "AgentSendErrors"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(newLogger())
+			require.NoError(t, r.Register(namedCollector{name: tt.name, metrics: metrics}, Settings{PollInterval: time.Hour}))
+			r.collect(context.Background(), r.entries[0])
+
+			var got []string
+			for _, v := range r.Metrics() {
+				got = append(got, v.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRuntime_Collect(t *testing.T) {
+	metrics, err := NewRuntime().Collect(context.Background())
+	require.NoError(t, err)
+
+	got := make(map[string]metric.Metric, len(metrics))
+	for _, v := range metrics {
+		got[v.Name] = v
+	}
+
+	for _, name := range []string{"Alloc", "GCCPUFraction", "RandomValue"} {
+		assert.Equal(t, metric.KindGauge, got[name].Kind, name)
+	}
+}
+
+func TestRegistry_Aggregate(t *testing.T) {
+	tests := []struct {
+		name      string
+		aggregate bool
+		want      map[string]float64
+	}{
+		{
+			name: "last only",
+			want: map[string]float64{"load": 3},
+		},
+		{
+			name:      "aggregate",
+			aggregate: true,
+			want: map[string]float64{
+				"load":      3,
+				"loadMin":   1,
+				"loadMax":   3,
+				"loadMean":  2,
+				"loadCount": 3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(newLogger())
+			require.NoError(t, r.Register(&fakeCollector{name: "load"}, Settings{PollInterval: time.Hour, Aggregate: tt.aggregate}))
+
+			e := r.entries[0]
+			for i := 0; i < 3; i++ {
+				r.collect(context.Background(), e)
+			}
+
+			got := make(map[string]float64)
+			for _, v := range r.Metrics() {
+				got[v.ID()] = v.Value
+			}
+			assert.Equal(t, tt.want, got)
+
+			// агрегаты сбрасываются после отправки, последнее значение остаётся.
+			got = make(map[string]float64)
+			for _, v := range r.Metrics() {
+				got[v.ID()] = v.Value
+			}
+			assert.Equal(t, map[string]float64{"load": 3}, got)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/load"
+)
+
+// cpuSource источник данных о процессоре, в тестах подменяется фейком.
+type cpuSource interface {
+	// Percent загрузка каждого ядра в процентах с момента предыдущего вызова.
+	Percent(ctx context.Context) ([]float64, error)
+	Load(ctx context.Context) (*load.AvgStat, error)
+	Misc(ctx context.Context) (*load.MiscStat, error)
+}
+
+type gopsutilCPU struct{}
+
+func (gopsutilCPU) Percent(ctx context.Context) ([]float64, error) {
+	return cpu.PercentWithContext(ctx, 0, true)
+}
+
+func (gopsutilCPU) Load(ctx context.Context) (*load.AvgStat, error) {
+	return load.AvgWithContext(ctx)
+}
+
+func (gopsutilCPU) Misc(ctx context.Context) (*load.MiscStat, error) {
+	return load.MiscWithContext(ctx)
+}
+
+// CPU коллектор загрузки процессора по ядрам, средней нагрузки и переключений контекста.
+// Переключения контекста отправляются как приращение с предыдущего опроса.
+type CPU struct {
+	source cpuSource
+
+	mu sync.Mutex
+	// число переключений контекста на предыдущем опросе, nil до первого опроса.
+	prevCtxt *uint64
+}
+
+func NewCPU() *CPU {
+	return &CPU{source: gopsutilCPU{}}
+}
+
+func (*CPU) Name() string {
+	return "cpu"
+}
+
+func (c *CPU) Collect(ctx context.Context) ([]metric.Metric, error) {
+	percent, err := c.source.Percent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("c.source.Percent: %w", err)
+	}
+
+	res := make([]metric.Metric, 0, len(percent)+4)
+	for i, v := range percent {
+		res = append(res, metric.NewGauge(fmt.Sprintf("CPUutilization%d", i+1), v))
+	}
+
+	avg, err := c.source.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("c.source.Load: %w", err)
+	}
+
+	res = append(res,
+		metric.NewGauge("Load1", avg.Load1),
+		metric.NewGauge("Load5", avg.Load5),
+		metric.NewGauge("Load15", avg.Load15),
+	)
+
+	misc, err := c.source.Misc(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("c.source.Misc: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// число переключений контекста накоплено с момента загрузки системы,
+	// на первом опросе запоминается только начальное значение.
+	ctxt := uint64(misc.Ctxt)
+	if c.prevCtxt != nil {
+		res = append(res, metric.NewCounter("ContextSwitches", delta(*c.prevCtxt, ctxt)))
+	}
+	c.prevCtxt = &ctxt
+
+	return res, nil
+}
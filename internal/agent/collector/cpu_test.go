@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCPU struct {
+	percent []float64
+	avg     *load.AvgStat
+	misc    *load.MiscStat
+	err     error
+}
+
+func (f fakeCPU) Percent(_ context.Context) ([]float64, error) {
+	return f.percent, f.err
+}
+
+func (f fakeCPU) Load(_ context.Context) (*load.AvgStat, error) {
+	return f.avg, nil
+}
+
+func (f fakeCPU) Misc(_ context.Context) (*load.MiscStat, error) {
+	return f.misc, nil
+}
+
+func TestCPU_Collect(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  fakeCPU
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name: "positive",
+			source: fakeCPU{
+				percent: []float64{12.5, 50, 99.9},
+				avg:     &load.AvgStat{Load1: 0.5, Load5: 1.5, Load15: 2.5},
+				misc:    &load.MiscStat{Ctxt: 1000},
+			},
+			want: map[string]float64{
+				"CPUutilization1": 12.5,
+				"CPUutilization2": 50,
+				"CPUutilization3": 99.9,
+				"Load1":           0.5,
+				"Load5":           1.5,
+				"Load15":          2.5,
+			},
+		},
+		{
+			name:    "source error",
+			source:  fakeCPU{err: errors.New("percent failed")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CPU{source: tt.source}
+			metrics, err := c.Collect(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make(map[string]float64, len(metrics))
+			for _, v := range metrics {
+				assert.Equal(t, metric.KindGauge, v.Kind, v.Name)
+				got[v.Name] = v.Value
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCPU_CollectContextSwitches(t *testing.T) {
+	source := fakeCPU{avg: &load.AvgStat{}, misc: &load.MiscStat{Ctxt: 1000}}
+	c := &CPU{source: source}
+
+	first, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	for _, v := range first {
+		assert.NotEqual(t, "ContextSwitches", v.Name, "first poll only records baseline")
+	}
+
+	source.misc = &load.MiscStat{Ctxt: 1500}
+	c.source = source
+	second, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	var got []metric.Metric
+	for _, v := range second {
+		if v.Name == "ContextSwitches" {
+			got = append(got, v)
+		}
+	}
+	require.Len(t, got, 1)
+	assert.Equal(t, metric.KindCounter, got[0].Kind)
+	assert.Equal(t, int64(500), got[0].Delta)
+}
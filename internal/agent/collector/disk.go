@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/disk"
+)
+
+// diskSource источник данных о дисках, в тестах подменяется фейком.
+type diskSource interface {
+	Partitions(ctx context.Context) ([]disk.PartitionStat, error)
+	Usage(ctx context.Context, path string) (*disk.UsageStat, error)
+	IOCounters(ctx context.Context) (map[string]disk.IOCountersStat, error)
+}
+
+type gopsutilDisk struct{}
+
+func (gopsutilDisk) Partitions(ctx context.Context) ([]disk.PartitionStat, error) {
+	return disk.PartitionsWithContext(ctx, false)
+}
+
+func (gopsutilDisk) Usage(ctx context.Context, path string) (*disk.UsageStat, error) {
+	return disk.UsageWithContext(ctx, path)
+}
+
+func (gopsutilDisk) IOCounters(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+	return disk.IOCountersWithContext(ctx)
+}
+
+// Disk коллектор заполненности файловых систем по точкам монтирования
+// и счётчиков ввода-вывода по устройствам.
+// Счётчики ввода-вывода отправляются как приращения с предыдущего опроса.
+type Disk struct {
+	source diskSource
+
+	mu      sync.RWMutex
+	mounts  Filter
+	fsTypes Filter
+
+	prevMu sync.Mutex
+	prev   map[string]disk.IOCountersStat
+}
+
+// NewDisk создаёт коллектор, отбирающий разделы по точке монтирования и типу файловой системы.
+func NewDisk(mounts, fsTypes Filter) *Disk {
+	return &Disk{
+		source:  gopsutilDisk{},
+		mounts:  mounts,
+		fsTypes: fsTypes,
+	}
+}
+
+// SetFilters заменяет фильтры разделов, новые фильтры действуют со следующего опроса.
+func (d *Disk) SetFilters(mounts, fsTypes Filter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.mounts = mounts
+	d.fsTypes = fsTypes
+}
+
+func (*Disk) Name() string {
+	return "disk"
+}
+
+func (d *Disk) Collect(ctx context.Context) ([]metric.Metric, error) {
+	d.mu.RLock()
+	mounts, fsTypes := d.mounts, d.fsTypes
+	d.mu.RUnlock()
+
+	partitions, err := d.source.Partitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("d.source.Partitions: %w", err)
+	}
+
+	var res []metric.Metric
+	devices := make(map[string]struct{})
+	for _, p := range partitions {
+		if !mounts.Match(p.Mountpoint) || !fsTypes.Match(p.Fstype) {
+			continue
+		}
+		devices[deviceName(p.Device)] = struct{}{}
+
+		usage, err := d.source.Usage(ctx, p.Mountpoint)
+		if err != nil {
+			// точка монтирования может быть недоступна агенту, остальные разделы всё равно отправляются.
+			continue
+		}
+
+		labels := map[string]string{"mount": p.Mountpoint, "fstype": p.Fstype}
+		res = append(res,
+			metric.NewGauge("DiskTotal", float64(usage.Total)).WithLabels(labels),
+			metric.NewGauge("DiskUsed", float64(usage.Used)).WithLabels(labels),
+			metric.NewGauge("DiskFree", float64(usage.Free)).WithLabels(labels),
+			metric.NewGauge("DiskInodesTotal", float64(usage.InodesTotal)).WithLabels(labels),
+			metric.NewGauge("DiskInodesUsed", float64(usage.InodesUsed)).WithLabels(labels),
+			metric.NewGauge("DiskInodesFree", float64(usage.InodesFree)).WithLabels(labels),
+		)
+	}
+
+	counters, err := d.source.IOCounters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("d.source.IOCounters: %w", err)
+	}
+
+	d.prevMu.Lock()
+	defer d.prevMu.Unlock()
+
+	cur := make(map[string]disk.IOCountersStat, len(counters))
+	for name, v := range counters {
+		// устройство LVM смонтировано как /dev/mapper/vg-root, а в счётчиках называется dm-N
+		// с именем device-mapper в Label.
+		_, ok := devices[name]
+		if !ok && v.Label != "" {
+			_, ok = devices[v.Label]
+		}
+		if !ok {
+			continue
+		}
+
+		cur[name] = v
+
+		// значения накоплены с момента загрузки системы,
+		// для нового устройства запоминается только начальное значение.
+		prev, ok := d.prev[name]
+		if !ok {
+			continue
+		}
+
+		labels := map[string]string{"device": name}
+		res = append(res,
+			metric.NewCounter("DiskReadBytes", delta(prev.ReadBytes, v.ReadBytes)).WithLabels(labels),
+			metric.NewCounter("DiskWriteBytes", delta(prev.WriteBytes, v.WriteBytes)).WithLabels(labels),
+			metric.NewCounter("DiskReadCount", delta(prev.ReadCount, v.ReadCount)).WithLabels(labels),
+			metric.NewCounter("DiskWriteCount", delta(prev.WriteCount, v.WriteCount)).WithLabels(labels),
+			metric.NewCounter("DiskIOTime", delta(prev.IoTime, v.IoTime)).WithLabels(labels),
+		)
+	}
+	d.prev = cur
+
+	return res, nil
+}
+
+// deviceName возвращает имя устройства раздела, как в счётчиках ввода-вывода.
+// Символьные ссылки, например /dev/disk/by-uuid/..., разрешаются в имя устройства.
+func deviceName(device string) string {
+	if path, err := filepath.EvalSymlinks(device); err == nil {
+		device = path
+	}
+
+	return filepath.Base(device)
+}
@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDisk struct {
+	partitions []disk.PartitionStat
+	usage      map[string]*disk.UsageStat
+	counters   map[string]disk.IOCountersStat
+}
+
+func (f fakeDisk) Partitions(_ context.Context) ([]disk.PartitionStat, error) {
+	return f.partitions, nil
+}
+
+func (f fakeDisk) Usage(_ context.Context, path string) (*disk.UsageStat, error) {
+	v, ok := f.usage[path]
+	if !ok {
+		return nil, errors.New("permission denied")
+	}
+
+	return v, nil
+}
+
+func (f fakeDisk) IOCounters(_ context.Context) (map[string]disk.IOCountersStat, error) {
+	return f.counters, nil
+}
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		value  string
+		want   bool
+	}{
+		{name: "empty", filter: Filter{}, value: "/", want: true},
+		{name: "include", filter: Filter{Include: []string{"/data*"}}, value: "/data1", want: true},
+		{name: "not included", filter: Filter{Include: []string{"/data*"}}, value: "/", want: false},
+		{name: "exclude wins", filter: Filter{Include: []string{"*"}, Exclude: []string{"tmpfs"}}, value: "tmpfs", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(tt.value))
+		})
+	}
+}
+
+func TestDisk_Collect(t *testing.T) {
+	source := fakeDisk{
+		partitions: []disk.PartitionStat{
+			{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+			{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"},
+			{Device: "tmpfs", Mountpoint: "/run", Fstype: "tmpfs"},
+			{Device: "/dev/sdc1", Mountpoint: "/secret", Fstype: "ext4"},
+			{Device: "/dev/mapper/vg-root", Mountpoint: "/srv", Fstype: "ext4"},
+		},
+		usage: map[string]*disk.UsageStat{
+			"/":     {Total: 100, Used: 40, Free: 60, InodesTotal: 10, InodesUsed: 4, InodesFree: 6},
+			"/data": {Total: 200, Used: 50, Free: 150},
+			"/run":  {Total: 1},
+			"/srv":  {Total: 300},
+		},
+		counters: map[string]disk.IOCountersStat{
+			"sda1":  {ReadBytes: 1024, WriteBytes: 2048, ReadCount: 1, WriteCount: 2, IoTime: 3},
+			"sdb1":  {ReadBytes: 1},
+			"sdc1":  {ReadBytes: 1},
+			"loop0": {ReadBytes: 1},
+			"dm-0":  {Label: "vg-root", ReadBytes: 7},
+		},
+	}
+	baseline := source
+	baseline.counters = map[string]disk.IOCountersStat{
+		"sda1": {},
+		"sdb1": {},
+		"sdc1": {},
+		"dm-0": {Label: "vg-root"},
+	}
+
+	tests := []struct {
+		name     string
+		mounts   Filter
+		fsTypes  Filter
+		gauges   map[string]float64
+		counters map[string]int64
+	}{
+		{
+			name:    "exclude mount and fs type",
+			mounts:  Filter{Exclude: []string{"/data", "/srv"}},
+			fsTypes: Filter{Exclude: []string{"tmpfs"}},
+			gauges: map[string]float64{
+				`DiskTotal{fstype="ext4",mount="/"}`:       100,
+				`DiskUsed{fstype="ext4",mount="/"}`:        40,
+				`DiskFree{fstype="ext4",mount="/"}`:        60,
+				`DiskInodesTotal{fstype="ext4",mount="/"}`: 10,
+				`DiskInodesUsed{fstype="ext4",mount="/"}`:  4,
+				`DiskInodesFree{fstype="ext4",mount="/"}`:  6,
+			},
+			counters: map[string]int64{
+				`DiskReadBytes{device="sda1"}`:  1024,
+				`DiskWriteBytes{device="sda1"}`: 2048,
+				`DiskReadCount{device="sda1"}`:  1,
+				`DiskWriteCount{device="sda1"}`: 2,
+				`DiskIOTime{device="sda1"}`:     3,
+				`DiskReadBytes{device="sdc1"}`:  1,
+				`DiskWriteBytes{device="sdc1"}`: 0,
+				`DiskReadCount{device="sdc1"}`:  0,
+				`DiskWriteCount{device="sdc1"}`: 0,
+				`DiskIOTime{device="sdc1"}`:     0,
+			},
+		},
+		{
+			name:   "include mount",
+			mounts: Filter{Include: []string{"/data"}},
+			gauges: map[string]float64{
+				`DiskTotal{fstype="xfs",mount="/data"}`:       200,
+				`DiskUsed{fstype="xfs",mount="/data"}`:        50,
+				`DiskFree{fstype="xfs",mount="/data"}`:        150,
+				`DiskInodesTotal{fstype="xfs",mount="/data"}`: 0,
+				`DiskInodesUsed{fstype="xfs",mount="/data"}`:  0,
+				`DiskInodesFree{fstype="xfs",mount="/data"}`:  0,
+			},
+			counters: map[string]int64{
+				`DiskReadBytes{device="sdb1"}`:  1,
+				`DiskWriteBytes{device="sdb1"}`: 0,
+				`DiskReadCount{device="sdb1"}`:  0,
+				`DiskWriteCount{device="sdb1"}`: 0,
+				`DiskIOTime{device="sdb1"}`:     0,
+			},
+		},
+		{
+			name:   "device mapper",
+			mounts: Filter{Include: []string{"/srv"}},
+			gauges: map[string]float64{
+				`DiskTotal{fstype="ext4",mount="/srv"}`:       300,
+				`DiskUsed{fstype="ext4",mount="/srv"}`:        0,
+				`DiskFree{fstype="ext4",mount="/srv"}`:        0,
+				`DiskInodesTotal{fstype="ext4",mount="/srv"}`: 0,
+				`DiskInodesUsed{fstype="ext4",mount="/srv"}`:  0,
+				`DiskInodesFree{fstype="ext4",mount="/srv"}`:  0,
+			},
+			counters: map[string]int64{
+				`DiskReadBytes{device="dm-0"}`:  7,
+				`DiskWriteBytes{device="dm-0"}`: 0,
+				`DiskReadCount{device="dm-0"}`:  0,
+				`DiskWriteCount{device="dm-0"}`: 0,
+				`DiskIOTime{device="dm-0"}`:     0,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Disk{source: baseline, mounts: tt.mounts, fsTypes: tt.fsTypes}
+
+			first, err := d.Collect(context.Background())
+			require.NoError(t, err)
+			for _, v := range first {
+				assert.Equal(t, metric.KindGauge, v.Kind, "first poll only records IO baseline")
+			}
+
+			d.source = source
+			second, err := d.Collect(context.Background())
+			require.NoError(t, err)
+
+			gauges := make(map[string]float64)
+			counters := make(map[string]int64)
+			for _, v := range second {
+				if v.Kind == metric.KindCounter {
+					counters[v.ID()] = v.Delta
+					continue
+				}
+				gauges[v.ID()] = v.Value
+			}
+			assert.Equal(t, tt.gauges, gauges)
+			assert.Equal(t, tt.counters, counters)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var (
+	ErrInvalidOutput = errors.New("invalid script output")
+)
+
+// Script команда, вывод которой разбирается в метрики.
+type Script struct {
+	// имя скрипта, коллектор называется exec:<Name>.
+	Name    string
+	Command string
+	Args    []string
+	// время выполнения, после которого команда завершается.
+	Timeout time.Duration
+	// формат вывода: text, json или пустая строка для автоопределения.
+	Format string
+}
+
+// scriptMetric метрика в JSON-выводе скрипта.
+type scriptMetric struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Value  json.Number       `json:"value"`
+	Labels map[string]string `json:"labels"`
+}
+
+// Exec коллектор, запускающий команду и разбирающий её stdout.
+// Текстовый формат - строки "name type value", пустые строки и строки с # пропускаются.
+// JSON формат - объект или массив объектов {"name", "type", "value", "labels"}.
+// Значение counter-метрики прибавляется к накопленному на сервере.
+type Exec struct {
+	script Script
+}
+
+func NewExec(script Script) Exec {
+	return Exec{script: script}
+}
+
+func (e Exec) Name() string {
+	return "exec:" + e.script.Name
+}
+
+func (e Exec) Collect(ctx context.Context) ([]metric.Metric, error) {
+	if e.script.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.script.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.script.Command, e.script.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cmd.Run: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseScriptOutput(stdout.Bytes(), e.script.Format)
+}
+
+// parseScriptOutput разбирает вывод скрипта в заданном формате.
+func parseScriptOutput(data []byte, format string) ([]metric.Metric, error) {
+	if format == "" {
+		format = FormatText
+		if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+			format = FormatJSON
+		}
+	}
+
+	switch format {
+	case FormatText:
+		return parseText(data)
+	case FormatJSON:
+		return parseJSON(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q: %w", format, ErrInvalidOutput)
+	}
+}
+
+func parseText(data []byte) ([]metric.Metric, error) {
+	var res []metric.Metric
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: %w", line, ErrInvalidOutput)
+		}
+
+		m, err := newScriptMetric(fields[0], fields[1], fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		res = append(res, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner.Err: %w", err)
+	}
+
+	return res, nil
+}
+
+func parseJSON(data []byte) ([]metric.Metric, error) {
+	data = bytes.TrimSpace(data)
+
+	var items []scriptMetric
+	if len(data) != 0 && data[0] == '{' {
+		var item scriptMetric
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		items = append(items, item)
+	} else if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	res := make([]metric.Metric, 0, len(items))
+	for _, v := range items {
+		m, err := newScriptMetric(v.Name, v.Type, v.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		if len(v.Labels) != 0 {
+			m = m.WithLabels(v.Labels)
+		}
+
+		res = append(res, m)
+	}
+
+	return res, nil
+}
+
+func newScriptMetric(name, kind, value string) (metric.Metric, error) {
+	if name == "" {
+		return metric.Metric{}, fmt.Errorf("empty name: %w", ErrInvalidOutput)
+	}
+
+	switch metric.Kind(kind) {
+	case metric.KindGauge:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return metric.Metric{}, fmt.Errorf("%s: %w", name, ErrInvalidOutput)
+		}
+
+		return metric.NewGauge(name, v), nil
+	case metric.KindCounter:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return metric.Metric{}, fmt.Errorf("%s: %w", name, ErrInvalidOutput)
+		}
+
+		return metric.NewCounter(name, v), nil
+	default:
+		return metric.Metric{}, fmt.Errorf("%s: unknown type %q: %w", name, kind, ErrInvalidOutput)
+	}
+}
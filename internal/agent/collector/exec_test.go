@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScriptOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		want    map[string]metric.Metric
+		wantErr error
+	}{
+		{
+			name: "text",
+			data: "# queue depth\nQueueDepth gauge 12.5\n\nJobsDone counter 3\n",
+			want: map[string]metric.Metric{
+				"QueueDepth": {Name: "QueueDepth", Kind: metric.KindGauge, Value: 12.5},
+				"JobsDone":   {Name: "JobsDone", Kind: metric.KindCounter, Delta: 3},
+			},
+		},
+		{
+			name: "json array",
+			data: `[{"name": "QueueDepth", "type": "gauge", "value": 1.5, "labels": {"queue": "mail"}}]`,
+			want: map[string]metric.Metric{
+				`QueueDepth{queue="mail"}`: {Name: "QueueDepth", Kind: metric.KindGauge, Value: 1.5, Labels: map[string]string{"queue": "mail"}},
+			},
+		},
+		{
+			name: "json object",
+			data: `{"name": "JobsDone", "type": "counter", "value": 7}`,
+			want: map[string]metric.Metric{
+				"JobsDone": {Name: "JobsDone", Kind: metric.KindCounter, Delta: 7},
+			},
+		},
+		{
+			name:    "wrong field count",
+			data:    "QueueDepth 12.5",
+			wantErr: ErrInvalidOutput,
+		},
+		{
+			name:    "fractional counter",
+			data:    "JobsDone counter 1.5",
+			wantErr: ErrInvalidOutput,
+		},
+		{
+			name:    "unknown type",
+			data:    "QueueDepth histogram 1",
+			wantErr: ErrInvalidOutput,
+		},
+		{
+			name:    "unknown format",
+			data:    "QueueDepth gauge 1",
+			format:  "xml",
+			wantErr: ErrInvalidOutput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := parseScriptOutput([]byte(tt.data), tt.format)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make(map[string]metric.Metric, len(metrics))
+			for _, v := range metrics {
+				v.Timestamp = time.Time{}
+				got[v.ID()] = v
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExec_Collect(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  Script
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "positive",
+			script: Script{Name: "echo", Command: "sh", Args: []string{"-c", "echo 'Up gauge 1'"}},
+			want:   1,
+		},
+		{
+			name:    "exit code",
+			script:  Script{Name: "fail", Command: "sh", Args: []string{"-c", "exit 1"}},
+			wantErr: true,
+		},
+		{
+			name:    "timeout",
+			script:  Script{Name: "sleep", Command: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExec(tt.script)
+			assert.Equal(t, "exec:"+tt.script.Name, e.Name())
+
+			metrics, err := e.Collect(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, metrics, tt.want)
+		})
+	}
+}
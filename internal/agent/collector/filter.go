@@ -0,0 +1,29 @@
+package collector
+
+import "path"
+
+// Filter отбирает значения по шаблонам path.Match.
+// Исключающие шаблоны имеют приоритет, пустой список включающих шаблонов пропускает всё.
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// Match сообщает, проходит ли значение s через фильтр.
+func (f Filter) Match(s string) bool {
+	if matchAny(f.Exclude, s) {
+		return false
+	}
+
+	return len(f.Include) == 0 || matchAny(f.Include, s)
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, v := range patterns {
+		if ok, _ := path.Match(v, s); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,32 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// Memory коллектор системной памяти хоста.
+type Memory struct{}
+
+func NewMemory() Memory {
+	return Memory{}
+}
+
+func (Memory) Name() string {
+	return "memory"
+}
+
+func (Memory) Collect(ctx context.Context) ([]metric.Metric, error) {
+	memory, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("mem.VirtualMemoryWithContext: %w", err)
+	}
+
+	return []metric.Metric{
+		metric.NewGauge("TotalMemory", float64(memory.Total)),
+		metric.NewGauge("FreeMemory", float64(memory.Free)),
+	}, nil
+}
@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/rs/zerolog"
+	"github.com/shirou/gopsutil/net"
+)
+
+// tcpStates состояния TCP-соединений, которые отправляются всегда, в том числе с нулевым числом соединений.
+var tcpStates = []string{
+	"ESTABLISHED", "SYN_SENT", "SYN_RECV", "FIN_WAIT1", "FIN_WAIT2", "TIME_WAIT",
+	"CLOSE", "CLOSE_WAIT", "LAST_ACK", "LISTEN", "CLOSING",
+}
+
+// netSource источник данных о сети, в тестах подменяется фейком.
+type netSource interface {
+	IOCounters(ctx context.Context) ([]net.IOCountersStat, error)
+	Connections(ctx context.Context) ([]net.ConnectionStat, error)
+}
+
+type gopsutilNet struct{}
+
+func (gopsutilNet) IOCounters(ctx context.Context) ([]net.IOCountersStat, error) {
+	return net.IOCountersWithContext(ctx, true)
+}
+
+func (gopsutilNet) Connections(ctx context.Context) ([]net.ConnectionStat, error) {
+	return net.ConnectionsWithoutUidsWithContext(ctx, "tcp")
+}
+
+// Network коллектор счётчиков сетевых интерфейсов и числа TCP-соединений по состояниям.
+// Счётчики интерфейсов отправляются как приращения с предыдущего опроса.
+type Network struct {
+	source     netSource
+	log        zerolog.Logger
+	interfaces Filter
+	tcp        bool
+
+	mu   sync.Mutex
+	prev map[string]net.IOCountersStat
+}
+
+// NewNetwork создаёт коллектор, отбирающий интерфейсы по имени.
+// Если tcp равен false, состояния TCP-соединений не собираются.
+func NewNetwork(interfaces Filter, tcp bool, log zerolog.Logger) *Network {
+	return &Network{
+		source:     gopsutilNet{},
+		log:        log,
+		interfaces: interfaces,
+		tcp:        tcp,
+	}
+}
+
+// SetFilter заменяет фильтр интерфейсов и включает или выключает сбор TCP-соединений.
+func (n *Network) SetFilter(interfaces Filter, tcp bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.interfaces = interfaces
+	n.tcp = tcp
+}
+
+func (*Network) Name() string {
+	return "network"
+}
+
+func (n *Network) Collect(ctx context.Context) ([]metric.Metric, error) {
+	counters, err := n.source.IOCounters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("n.source.IOCounters: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var res []metric.Metric
+	cur := make(map[string]net.IOCountersStat, len(counters))
+	for _, v := range counters {
+		if !n.interfaces.Match(v.Name) {
+			continue
+		}
+		cur[v.Name] = v
+
+		// для нового интерфейса запоминается только начальное значение.
+		prev, ok := n.prev[v.Name]
+		if !ok {
+			continue
+		}
+
+		labels := map[string]string{"interface": v.Name}
+		res = append(res,
+			metric.NewCounter("NetBytesSent", delta(prev.BytesSent, v.BytesSent)).WithLabels(labels),
+			metric.NewCounter("NetBytesRecv", delta(prev.BytesRecv, v.BytesRecv)).WithLabels(labels),
+			metric.NewCounter("NetPacketsSent", delta(prev.PacketsSent, v.PacketsSent)).WithLabels(labels),
+			metric.NewCounter("NetPacketsRecv", delta(prev.PacketsRecv, v.PacketsRecv)).WithLabels(labels),
+			metric.NewCounter("NetErrIn", delta(prev.Errin, v.Errin)).WithLabels(labels),
+			metric.NewCounter("NetErrOut", delta(prev.Errout, v.Errout)).WithLabels(labels),
+			metric.NewCounter("NetDropIn", delta(prev.Dropin, v.Dropin)).WithLabels(labels),
+			metric.NewCounter("NetDropOut", delta(prev.Dropout, v.Dropout)).WithLabels(labels),
+		)
+	}
+	n.prev = cur
+
+	if !n.tcp {
+		return res, nil
+	}
+
+	conns, err := n.source.Connections(ctx)
+	if err != nil {
+		// например, нет прав на чтение /proc/net/tcp, счётчики интерфейсов всё равно отправляются,
+		// иначе их приращения с прошлого опроса были бы потеряны.
+		n.log.Warn().Err(err).Msg("n.source.Connections")
+		return res, nil
+	}
+
+	states := make(map[string]int, len(tcpStates))
+	for _, v := range conns {
+		states[v.Status]++
+	}
+
+	for _, v := range tcpStates {
+		res = append(res, metric.NewGauge("TCPConnections", float64(states[v])).WithLabels(map[string]string{"state": v}))
+	}
+
+	return res, nil
+}
+
+// maxWrapDelta наибольшее приращение, при котором уменьшение счётчика считается переполнением 32 бит.
+const maxWrapDelta = 1 << 30
+
+// delta возвращает приращение счётчика между двумя опросами.
+// Счётчики /proc/net/dev 64-битные, поэтому уменьшение обычно означает сброс счётчика,
+// например при пересоздании интерфейса. Переполнением 32-битного счётчика уменьшение считается,
+// только если предыдущее значение было близко к 2^32 и приращение через переполнение невелико.
+func delta(prev, cur uint64) int64 {
+	if cur >= prev {
+		return int64(cur - prev)
+	}
+
+	if prev <= math.MaxUint32 {
+		if wrapped := math.MaxUint32 - prev + cur + 1; wrapped <= maxWrapDelta {
+			return int64(wrapped)
+		}
+	}
+
+	return int64(cur)
+}
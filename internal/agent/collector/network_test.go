@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNet struct {
+	counters [][]net.IOCountersStat
+	conns    []net.ConnectionStat
+	connsErr error
+	poll     int
+}
+
+func (f *fakeNet) IOCounters(_ context.Context) ([]net.IOCountersStat, error) {
+	res := f.counters[f.poll]
+	f.poll++
+
+	return res, nil
+}
+
+func (f *fakeNet) Connections(_ context.Context) ([]net.ConnectionStat, error) {
+	return f.conns, f.connsErr
+}
+
+func TestDelta(t *testing.T) {
+	tests := []struct {
+		name string
+		prev uint64
+		cur  uint64
+		want int64
+	}{
+		{name: "growth", prev: 100, cur: 150, want: 50},
+		{name: "no change", prev: 100, cur: 100, want: 0},
+		{name: "32-bit wraparound", prev: math.MaxUint32 - 9, cur: 5, want: 15},
+		{name: "reset", prev: math.MaxUint32 + 100, cur: 7, want: 7},
+		{name: "reset with small prev", prev: 1000, cur: 7, want: 7},
+		{name: "reset far from 32-bit limit", prev: math.MaxUint32 / 2, cur: 100, want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, delta(tt.prev, tt.cur))
+		})
+	}
+}
+
+func TestNetwork_Collect(t *testing.T) {
+	source := &fakeNet{
+		counters: [][]net.IOCountersStat{
+			{
+				{Name: "eth0", BytesSent: 1000, BytesRecv: math.MaxUint32 - 4},
+				{Name: "lo", BytesSent: 1},
+			},
+			{
+				{Name: "eth0", BytesSent: 1500, BytesRecv: 5, PacketsSent: 3, Errin: 1, Dropout: 2},
+				{Name: "lo", BytesSent: 2},
+				{Name: "eth1", BytesSent: 10},
+			},
+		},
+		conns: []net.ConnectionStat{
+			{Status: "ESTABLISHED"},
+			{Status: "ESTABLISHED"},
+			{Status: "LISTEN"},
+		},
+	}
+
+	n := &Network{source: source, interfaces: Filter{Exclude: []string{"lo"}}, tcp: true}
+
+	first, err := n.Collect(context.Background())
+	require.NoError(t, err)
+	for _, v := range first {
+		assert.Equal(t, "TCPConnections", v.Name, "first poll only records baseline")
+	}
+
+	second, err := n.Collect(context.Background())
+	require.NoError(t, err)
+
+	counters := make(map[string]int64)
+	gauges := make(map[string]float64)
+	for _, v := range second {
+		if v.Kind == metric.KindCounter {
+			counters[v.ID()] = v.Delta
+			continue
+		}
+		gauges[v.ID()] = v.Value
+	}
+
+	assert.Equal(t, map[string]int64{
+		`NetBytesSent{interface="eth0"}`:   500,
+		`NetBytesRecv{interface="eth0"}`:   10,
+		`NetPacketsSent{interface="eth0"}`: 3,
+		`NetPacketsRecv{interface="eth0"}`: 0,
+		`NetErrIn{interface="eth0"}`:       1,
+		`NetErrOut{interface="eth0"}`:      0,
+		`NetDropIn{interface="eth0"}`:      0,
+		`NetDropOut{interface="eth0"}`:     2,
+	}, counters)
+
+	assert.Len(t, gauges, len(tcpStates))
+	assert.Equal(t, 2.0, gauges[`TCPConnections{state="ESTABLISHED"}`])
+	assert.Equal(t, 1.0, gauges[`TCPConnections{state="LISTEN"}`])
+	assert.Equal(t, 0.0, gauges[`TCPConnections{state="TIME_WAIT"}`])
+}
+
+func TestNetwork_CollectWithoutTCP(t *testing.T) {
+	source := &fakeNet{counters: [][]net.IOCountersStat{{{Name: "eth0"}}}}
+
+	n := &Network{source: source}
+	metrics, err := n.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, metrics)
+}
+
+func TestNetwork_CollectConnectionsError(t *testing.T) {
+	source := &fakeNet{
+		counters: [][]net.IOCountersStat{
+			{{Name: "eth0", BytesSent: 100}},
+			{{Name: "eth0", BytesSent: 150}},
+		},
+		connsErr: errors.New("permission denied"),
+	}
+
+	n := &Network{source: source, log: newLogger(), tcp: true}
+	_, err := n.Collect(context.Background())
+	require.NoError(t, err)
+
+	// приращения интерфейсов не теряются, если не удалось получить TCP-соединения.
+	metrics, err := n.Collect(context.Background())
+	require.NoError(t, err)
+
+	got := make(map[string]int64)
+	for _, v := range metrics {
+		got[v.ID()] = v.Delta
+	}
+	assert.Equal(t, int64(50), got[`NetBytesSent{interface="eth0"}`])
+	assert.NotContains(t, got, `TCPConnections{state="LISTEN"}`)
+}
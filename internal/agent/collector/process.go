@@ -0,0 +1,275 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/shirou/gopsutil/process"
+)
+
+var (
+	ErrInvalidTarget = errors.New("process target must have name, pidfile or cmdline")
+)
+
+// ProcessTarget отслеживаемый процесс.
+// Если задан PidFile, процесс берётся из него, иначе процесс должен совпасть и по Name, и по Cmdline, если они заданы.
+type ProcessTarget struct {
+	// значение метки process, по нему различаются ряды метрик.
+	Label string
+	// точное имя исполняемого файла.
+	Name string
+	// путь к файлу с pid процесса.
+	PidFile string
+	// регулярное выражение для командной строки.
+	Cmdline string
+}
+
+// processStats показатели одного процесса.
+type processStats struct {
+	// суммарное время процессора в пользовательском и системном режимах, секунды.
+	CPUTime    float64
+	RSS        uint64
+	FDs        int32
+	Threads    int32
+	CreateTime time.Time
+}
+
+// processSource источник данных о процессах, в тестах подменяется фейком.
+type processSource interface {
+	Pids(ctx context.Context) ([]int32, error)
+	Name(ctx context.Context, pid int32) (string, error)
+	Cmdline(ctx context.Context, pid int32) (string, error)
+	Stats(ctx context.Context, pid int32) (processStats, error)
+}
+
+type gopsutilProcess struct{}
+
+func (gopsutilProcess) Pids(ctx context.Context) ([]int32, error) {
+	return process.PidsWithContext(ctx)
+}
+
+func (gopsutilProcess) Name(ctx context.Context, pid int32) (string, error) {
+	return (&process.Process{Pid: pid}).NameWithContext(ctx)
+}
+
+func (gopsutilProcess) Cmdline(ctx context.Context, pid int32) (string, error) {
+	return (&process.Process{Pid: pid}).CmdlineWithContext(ctx)
+}
+
+func (gopsutilProcess) Stats(ctx context.Context, pid int32) (processStats, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return processStats{}, fmt.Errorf("process.NewProcessWithContext: %w", err)
+	}
+
+	times, err := p.TimesWithContext(ctx)
+	if err != nil {
+		return processStats{}, fmt.Errorf("p.TimesWithContext: %w", err)
+	}
+
+	memory, err := p.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return processStats{}, fmt.Errorf("p.MemoryInfoWithContext: %w", err)
+	}
+
+	fds, err := p.NumFDsWithContext(ctx)
+	if err != nil {
+		return processStats{}, fmt.Errorf("p.NumFDsWithContext: %w", err)
+	}
+
+	threads, err := p.NumThreadsWithContext(ctx)
+	if err != nil {
+		return processStats{}, fmt.Errorf("p.NumThreadsWithContext: %w", err)
+	}
+
+	createTime, err := p.CreateTimeWithContext(ctx)
+	if err != nil {
+		return processStats{}, fmt.Errorf("p.CreateTimeWithContext: %w", err)
+	}
+
+	return processStats{
+		CPUTime:    times.User + times.System,
+		RSS:        memory.RSS,
+		FDs:        fds,
+		Threads:    threads,
+		CreateTime: time.UnixMilli(createTime),
+	}, nil
+}
+
+type processTarget struct {
+	ProcessTarget
+	cmdline *regexp.Regexp
+}
+
+type cpuSample struct {
+	cpuTime float64
+	at      time.Time
+}
+
+// processState состояние отслеживаемого процесса между опросами.
+type processState struct {
+	seen       bool
+	createTime time.Time
+}
+
+// Processes коллектор показателей выбранных процессов.
+// Если под цель подходят несколько процессов, их показатели суммируются,
+// а время работы и перезапуски считаются по самому старому из них.
+type Processes struct {
+	source  processSource
+	targets []processTarget
+	now     func() time.Time
+
+	mu     sync.Mutex
+	cpu    map[int32]cpuSample
+	states map[string]processState
+}
+
+func NewProcesses(targets []ProcessTarget) (*Processes, error) {
+	res := &Processes{
+		source: gopsutilProcess{},
+		now:    time.Now,
+		cpu:    make(map[int32]cpuSample),
+		states: make(map[string]processState),
+	}
+
+	for _, v := range targets {
+		if v.Name == "" && v.PidFile == "" && v.Cmdline == "" {
+			return nil, fmt.Errorf("%s: %w", v.Label, ErrInvalidTarget)
+		}
+
+		t := processTarget{ProcessTarget: v}
+		if v.Cmdline != "" {
+			re, err := regexp.Compile(v.Cmdline)
+			if err != nil {
+				return nil, fmt.Errorf("regexp.Compile: %w", err)
+			}
+			t.cmdline = re
+		}
+
+		res.targets = append(res.targets, t)
+	}
+
+	return res, nil
+}
+
+func (*Processes) Name() string {
+	return "process"
+}
+
+func (p *Processes) Collect(ctx context.Context) ([]metric.Metric, error) {
+	pids, err := p.source.Pids(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("p.source.Pids: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	cpu := make(map[int32]cpuSample)
+
+	var res []metric.Metric
+	for _, t := range p.targets {
+		labels := map[string]string{"process": t.Label}
+
+		var (
+			count   int
+			percent float64
+			rss     uint64
+			fds     int32
+			threads int32
+			oldest  time.Time
+		)
+		for _, pid := range p.match(ctx, t, pids) {
+			stats, err := p.source.Stats(ctx, pid)
+			if err != nil {
+				// процесс мог завершиться между получением списка и опросом.
+				continue
+			}
+
+			if prev, ok := p.cpu[pid]; ok && now.After(prev.at) {
+				percent += (stats.CPUTime - prev.cpuTime) / now.Sub(prev.at).Seconds() * 100
+			}
+			cpu[pid] = cpuSample{cpuTime: stats.CPUTime, at: now}
+
+			count++
+			rss += stats.RSS
+			fds += stats.FDs
+			threads += stats.Threads
+			if oldest.IsZero() || stats.CreateTime.Before(oldest) {
+				oldest = stats.CreateTime
+			}
+		}
+
+		res = append(res, metric.NewGauge("ProcessCount", float64(count)).WithLabels(labels))
+		if count == 0 {
+			continue
+		}
+
+		var restarts int64
+		state := p.states[t.Label]
+		if state.seen && !state.createTime.Equal(oldest) {
+			restarts = 1
+		}
+		p.states[t.Label] = processState{seen: true, createTime: oldest}
+
+		res = append(res,
+			metric.NewGauge("ProcessCPUPercent", percent).WithLabels(labels),
+			metric.NewGauge("ProcessRSS", float64(rss)).WithLabels(labels),
+			metric.NewGauge("ProcessFDs", float64(fds)).WithLabels(labels),
+			metric.NewGauge("ProcessThreads", float64(threads)).WithLabels(labels),
+			metric.NewGauge("ProcessUptime", now.Sub(oldest).Seconds()).WithLabels(labels),
+			metric.NewCounter("ProcessRestarts", restarts).WithLabels(labels),
+		)
+	}
+	p.cpu = cpu
+
+	return res, nil
+}
+
+// match возвращает pid процессов, подходящих под цель.
+func (p *Processes) match(ctx context.Context, t processTarget, pids []int32) []int32 {
+	if t.PidFile != "" {
+		data, err := os.ReadFile(t.PidFile)
+		if err != nil {
+			return nil
+		}
+
+		pid, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+		if err != nil {
+			return nil
+		}
+
+		return []int32{int32(pid)}
+	}
+
+	var res []int32
+	for _, pid := range pids {
+		if t.Name != "" {
+			name, err := p.source.Name(ctx, pid)
+			if err != nil || name != t.Name {
+				continue
+			}
+		}
+
+		if t.cmdline != nil {
+			cmdline, err := p.source.Cmdline(ctx, pid)
+			if err != nil || !t.cmdline.MatchString(cmdline) {
+				continue
+			}
+		}
+
+		res = append(res, pid)
+	}
+
+	return res
+}
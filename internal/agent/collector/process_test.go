@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcess struct {
+	name    string
+	cmdline string
+	stats   processStats
+}
+
+type fakeProcesses map[int32]fakeProcess
+
+func (f fakeProcesses) Pids(_ context.Context) ([]int32, error) {
+	res := make([]int32, 0, len(f))
+	for pid := range f {
+		res = append(res, pid)
+	}
+
+	return res, nil
+}
+
+func (f fakeProcesses) Name(_ context.Context, pid int32) (string, error) {
+	return f[pid].name, nil
+}
+
+func (f fakeProcesses) Cmdline(_ context.Context, pid int32) (string, error) {
+	return f[pid].cmdline, nil
+}
+
+func (f fakeProcesses) Stats(_ context.Context, pid int32) (processStats, error) {
+	v, ok := f[pid]
+	if !ok {
+		return processStats{}, errors.New("process not found")
+	}
+
+	return v.stats, nil
+}
+
+func TestNewProcesses(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []ProcessTarget
+		wantErr bool
+	}{
+		{name: "positive", targets: []ProcessTarget{{Label: "api", Cmdline: "^api .*"}}},
+		{name: "empty target", targets: []ProcessTarget{{Label: "api"}}, wantErr: true},
+		{name: "invalid regexp", targets: []ProcessTarget{{Label: "api", Cmdline: "("}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProcesses(tt.targets)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestProcesses_Collect(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+
+	pidFile := filepath.Join(t.TempDir(), "db.pid")
+	require.NoError(t, os.WriteFile(pidFile, []byte("30\n"), 0o600))
+
+	source := fakeProcesses{
+		10: {name: "api", cmdline: "api --port 80", stats: processStats{CPUTime: 10, RSS: 100, FDs: 5, Threads: 2, CreateTime: start}},
+		11: {name: "api", cmdline: "api --port 81", stats: processStats{CPUTime: 20, RSS: 200, FDs: 6, Threads: 3, CreateTime: start.Add(time.Minute)}},
+		20: {name: "worker", cmdline: "worker", stats: processStats{CreateTime: start}},
+		30: {name: "postgres", stats: processStats{RSS: 1000, CreateTime: start}},
+	}
+
+	p, err := NewProcesses([]ProcessTarget{
+		{Label: "api", Name: "api", Cmdline: "--port"},
+		{Label: "db", PidFile: pidFile},
+		{Label: "cron", Name: "cron"},
+	})
+	require.NoError(t, err)
+	p.source = source
+	p.now = func() time.Time { return now }
+
+	collect := func() (map[string]float64, map[string]int64) {
+		metrics, err := p.Collect(context.Background())
+		require.NoError(t, err)
+
+		gauges := make(map[string]float64)
+		counters := make(map[string]int64)
+		for _, v := range metrics {
+			gauges[v.ID()] = v.Value
+			counters[v.ID()] = v.Delta
+		}
+
+		return gauges, counters
+	}
+
+	gauges, counters := collect()
+	assert.Equal(t, 2.0, gauges[`ProcessCount{process="api"}`])
+	assert.Equal(t, 300.0, gauges[`ProcessRSS{process="api"}`])
+	assert.Equal(t, 11.0, gauges[`ProcessFDs{process="api"}`])
+	assert.Equal(t, 5.0, gauges[`ProcessThreads{process="api"}`])
+	assert.Equal(t, time.Hour.Seconds(), gauges[`ProcessUptime{process="api"}`])
+	assert.Equal(t, 0.0, gauges[`ProcessCPUPercent{process="api"}`])
+	assert.Equal(t, int64(0), counters[`ProcessRestarts{process="api"}`])
+	assert.Equal(t, 1000.0, gauges[`ProcessRSS{process="db"}`])
+	assert.Equal(t, 0.0, gauges[`ProcessCount{process="cron"}`])
+	assert.NotContains(t, gauges, `ProcessRSS{process="cron"}`)
+
+	// за 10 секунд процессы api потратили 5 секунд процессора.
+	now = now.Add(10 * time.Second)
+	api := source[10]
+	api.stats.CPUTime += 2
+	source[10] = api
+	api = source[11]
+	api.stats.CPUTime += 3
+	source[11] = api
+
+	gauges, counters = collect()
+	assert.InDelta(t, 50.0, gauges[`ProcessCPUPercent{process="api"}`], 0.001)
+	assert.Equal(t, int64(0), counters[`ProcessRestarts{process="db"}`])
+
+	// postgres перезапущен с новым pid.
+	delete(source, 30)
+	source[31] = fakeProcess{name: "postgres", stats: processStats{CreateTime: now}}
+	require.NoError(t, os.WriteFile(pidFile, []byte("31"), 0o600))
+
+	gauges, counters = collect()
+	assert.Equal(t, int64(1), counters[`ProcessRestarts{process="db"}`])
+	assert.Equal(t, 0.0, gauges[`ProcessUptime{process="db"}`])
+	assert.Equal(t, int64(0), counters[`ProcessRestarts{process="api"}`])
+}
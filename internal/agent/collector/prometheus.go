@@ -0,0 +1,252 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+)
+
+var (
+	ErrInvalidExposition = errors.New("invalid prometheus exposition")
+)
+
+// ScrapeTarget HTTP-адрес, отдающий метрики в текстовом формате Prometheus.
+type ScrapeTarget struct {
+	// имя цели, коллектор называется prometheus:<Name>, метрики получают метку job.
+	Name    string
+	URL     string
+	Timeout time.Duration
+}
+
+// sample одно значение из текстового формата Prometheus.
+type sample struct {
+	name   string
+	kind   string
+	labels map[string]string
+	value  float64
+}
+
+// Prometheus коллектор, забирающий метрики с HTTP-адреса в текстовом формате Prometheus.
+// Gauge и untyped метрики отправляются как gauge, counter - как приращение с предыдущего опроса.
+// Histogram и summary пропускаются.
+type Prometheus struct {
+	target ScrapeTarget
+	client *http.Client
+
+	mu   sync.Mutex
+	prev map[string]float64
+}
+
+func NewPrometheus(target ScrapeTarget) *Prometheus {
+	return &Prometheus{
+		target: target,
+		client: &http.Client{Timeout: target.Timeout},
+	}
+}
+
+func (p *Prometheus) Name() string {
+	return "prometheus:" + p.target.Name
+}
+
+func (p *Prometheus) Collect(ctx context.Context) ([]metric.Metric, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.target.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("p.client.Do: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrape %s: status %d", p.target.URL, resp.StatusCode)
+	}
+
+	samples, err := parseExposition(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var res []metric.Metric
+	cur := make(map[string]float64)
+	for _, v := range samples {
+		labels := map[string]string{"job": p.target.Name}
+		for k, l := range v.labels {
+			labels[k] = l
+		}
+
+		switch v.kind {
+		case "gauge", "untyped":
+			res = append(res, metric.NewGauge(v.name, v.value).WithLabels(labels))
+		case "counter":
+			m := metric.NewCounter(v.name, 0).WithLabels(labels)
+			cur[m.ID()] = v.value
+
+			// для нового счётчика запоминается только начальное значение.
+			prev, ok := p.prev[m.ID()]
+			if !ok {
+				continue
+			}
+
+			// уменьшение значения означает перезапуск приложения, счётчик начался с нуля.
+			if v.value < prev {
+				prev = 0
+			}
+			m.Delta = int64(math.Floor(v.value)) - int64(math.Floor(prev))
+			res = append(res, m)
+		}
+	}
+	p.prev = cur
+
+	return res, nil
+}
+
+// parseExposition разбирает текстовый формат Prometheus.
+// Значения NaN и ±Inf пропускаются, так как не передаются сервером.
+func parseExposition(r io.Reader) ([]sample, error) {
+	kinds := make(map[string]string)
+
+	var res []sample
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "#") {
+			fields := strings.Fields(text)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				kinds[fields[2]] = fields[3]
+			}
+			continue
+		}
+
+		s, err := parseSample(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+			continue
+		}
+
+		s.kind = sampleKind(kinds, s.name)
+		res = append(res, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner.Err: %w", err)
+	}
+
+	return res, nil
+}
+
+// sampleKind возвращает тип семейства, к которому относится значение.
+func sampleKind(kinds map[string]string, name string) string {
+	if kind, ok := kinds[name]; ok {
+		return kind
+	}
+
+	for _, suffix := range []string{"_total", "_bucket", "_sum", "_count", "_created"} {
+		if kind, ok := kinds[strings.TrimSuffix(name, suffix)]; ok && strings.HasSuffix(name, suffix) {
+			return kind
+		}
+	}
+
+	return "untyped"
+}
+
+// parseSample разбирает строку вида name{label="value",...} value [timestamp].
+func parseSample(text string) (sample, error) {
+	s := sample{labels: make(map[string]string)}
+
+	i := strings.IndexAny(text, "{ \t")
+	if i <= 0 {
+		return sample{}, ErrInvalidExposition
+	}
+	s.name = text[:i]
+	rest := text[i:]
+
+	if rest[0] == '{' {
+		var err error
+		rest, err = parseLabels(rest[1:], s.labels)
+		if err != nil {
+			return sample{}, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample{}, ErrInvalidExposition
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample{}, fmt.Errorf("strconv.ParseFloat: %w", ErrInvalidExposition)
+	}
+	s.value = value
+
+	return s, nil
+}
+
+// parseLabels разбирает метки до закрывающей скобки и возвращает остаток строки.
+func parseLabels(text string, labels map[string]string) (string, error) {
+	for {
+		text = strings.TrimLeft(text, " \t,")
+		if text == "" {
+			return "", ErrInvalidExposition
+		}
+		if text[0] == '}' {
+			return text[1:], nil
+		}
+
+		eq := strings.IndexByte(text, '=')
+		if eq <= 0 || len(text) < eq+2 || text[eq+1] != '"' {
+			return "", ErrInvalidExposition
+		}
+		name := strings.TrimSpace(text[:eq])
+		text = text[eq+2:]
+
+		var b strings.Builder
+		closed := false
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			if c == '\\' && i+1 < len(text) {
+				i++
+				switch text[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(text[i])
+				}
+				continue
+			}
+			if c == '"' {
+				text = text[i+1:]
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return "", ErrInvalidExposition
+		}
+
+		labels[name] = b.String()
+	}
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExposition(t *testing.T) {
+	data := `# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="get",path="/a\"b\\c"} 3
+# TYPE queue_size gauge
+queue_size 12.5
+# TYPE jobs counter
+jobs_total 7
+temperature{room="a b"} -1.5e1
+# TYPE latency histogram
+latency_bucket{le="0.1"} 5
+latency_sum 1.2
+broken NaN
+`
+
+	samples, err := parseExposition(strings.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, []sample{
+		{name: "http_requests_total", kind: "counter", labels: map[string]string{"method": "post", "code": "200"}, value: 1027},
+		{name: "http_requests_total", kind: "counter", labels: map[string]string{"method": "get", "path": `/a"b\c`}, value: 3},
+		{name: "queue_size", kind: "gauge", labels: map[string]string{}, value: 12.5},
+		{name: "jobs_total", kind: "counter", labels: map[string]string{}, value: 7},
+		{name: "temperature", kind: "untyped", labels: map[string]string{"room": "a b"}, value: -15},
+		{name: "latency_bucket", kind: "histogram", labels: map[string]string{"le": "0.1"}, value: 5},
+		{name: "latency_sum", kind: "histogram", labels: map[string]string{}, value: 1.2},
+	}, samples)
+}
+
+func TestParseExposition_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`metric{label="value} 1`,
+		`metric{label=value} 1`,
+		`metric abc`,
+		`metric 1 2 3`,
+	} {
+		_, err := parseExposition(strings.NewReader(data))
+		assert.ErrorIs(t, err, ErrInvalidExposition, data)
+	}
+}
+
+func TestPrometheus_Collect(t *testing.T) {
+	var requests atomic.Int64
+	bodies := []string{
+		"# TYPE hits counter\nhits 10\n# TYPE up gauge\nup 1\n",
+		"# TYPE hits counter\nhits 25.5\n# TYPE up gauge\nup 1\n",
+		"# TYPE hits counter\nhits 4\n# TYPE up gauge\nup 0\n",
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1) - 1
+		_, _ = w.Write([]byte(bodies[n]))
+	}))
+	defer srv.Close()
+
+	p := NewPrometheus(ScrapeTarget{Name: "app", URL: srv.URL})
+	assert.Equal(t, "prometheus:app", p.Name())
+
+	collect := func() map[string]metric.Metric {
+		metrics, err := p.Collect(context.Background())
+		require.NoError(t, err)
+
+		res := make(map[string]metric.Metric, len(metrics))
+		for _, v := range metrics {
+			res[v.ID()] = v
+		}
+
+		return res
+	}
+
+	got := collect()
+	assert.Equal(t, 1.0, got[`up{job="app"}`].Value)
+	assert.NotContains(t, got, `hits{job="app"}`, "first scrape only records counter baseline")
+
+	got = collect()
+	assert.Equal(t, metric.KindCounter, got[`hits{job="app"}`].Kind)
+	assert.Equal(t, int64(15), got[`hits{job="app"}`].Delta)
+
+	got = collect()
+	assert.Equal(t, int64(4), got[`hits{job="app"}`].Delta, "counter reset")
+	assert.Equal(t, 0.0, got[`up{job="app"}`].Value)
+}
+
+func TestPrometheus_CollectStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := NewPrometheus(ScrapeTarget{Name: "app", URL: srv.URL}).Collect(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	random "math/rand"
+	"runtime"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+)
+
+// Runtime коллектор статистики памяти рантайма Go.
+type Runtime struct{}
+
+func NewRuntime() Runtime {
+	return Runtime{}
+}
+
+func (Runtime) Name() string {
+	return "runtime"
+}
+
+func (Runtime) Collect(_ context.Context) ([]metric.Metric, error) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return []metric.Metric{
+		metric.NewGauge("Alloc", float64(m.Alloc)),
+		metric.NewGauge("BuckHashSys", float64(m.BuckHashSys)),
+		metric.NewGauge("Frees", float64(m.Frees)),
+		metric.NewGauge("GCCPUFraction", m.GCCPUFraction),
+		metric.NewGauge("GCSys", float64(m.GCSys)),
+		metric.NewGauge("HeapAlloc", float64(m.HeapAlloc)),
+		metric.NewGauge("HeapIdle", float64(m.HeapIdle)),
+		metric.NewGauge("HeapInuse", float64(m.HeapInuse)),
+		metric.NewGauge("HeapObjects", float64(m.HeapObjects)),
+		metric.NewGauge("HeapReleased", float64(m.HeapReleased)),
+		metric.NewGauge("HeapSys", float64(m.HeapSys)),
+		metric.NewGauge("LastGC", float64(m.LastGC)),
+		metric.NewGauge("Lookups", float64(m.Lookups)),
+		metric.NewGauge("MCacheInuse", float64(m.MCacheInuse)),
+		metric.NewGauge("MCacheSys", float64(m.MCacheSys)),
+		metric.NewGauge("MSpanInuse", float64(m.MSpanInuse)),
+		metric.NewGauge("MSpanSys", float64(m.MSpanSys)),
+		metric.NewGauge("Mallocs", float64(m.Mallocs)),
+		metric.NewGauge("NextGC", float64(m.NextGC)),
+		metric.NewGauge("NumForcedGC", float64(m.NumForcedGC)),
+		metric.NewGauge("NumGC", float64(m.NumGC)),
+		metric.NewGauge("OtherSys", float64(m.OtherSys)),
+		metric.NewGauge("PauseTotalNs", float64(m.PauseTotalNs)),
+		metric.NewGauge("StackInuse", float64(m.StackInuse)),
+		metric.NewGauge("StackSys", float64(m.StackSys)),
+		metric.NewGauge("Sys", float64(m.Sys)),
+		metric.NewGauge("TotalAlloc", float64(m.TotalAlloc)),
+		metric.NewGauge("RandomValue", random.ExpFloat64()),
+	}, nil
+}
@@ -2,10 +2,12 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -15,14 +17,132 @@ const (
 	intervalReport = 0
 	intervalPull   = 0
 	rateLimit      = 0
+
+	healthCheckInterval = 5 * time.Second
+	spoolMaxSize        = 64 << 20
+	requestTimeout      = 10 * time.Second
+	shutdownTimeout     = 10 * time.Second
+
+	retryAttempts       = 3
+	retryInitialBackoff = time.Second
+	retryMaxBackoff     = 5 * time.Second
+	retryMultiplier     = 2
+	retryJitter         = 0.2
+
+	batchMaxCount = 100
+	batchMaxBytes = 64 << 10
+
+	scriptTimeout = 10 * time.Second
+	scrapeTimeout = 5 * time.Second
+
+	changeHeartbeat = 5 * time.Minute
+
+	httpMaxIdleConnsPerHost = 16
+	httpIdleConnTimeout     = 90 * time.Second
+)
+
+var (
+	ErrInvalidInterval = errors.New("interval must be positive")
 )
 
 type ConfigFile struct {
-	MetricServerAddr string `json:"address"`
-	PollInterval     string `json:"poll_interval"`
-	ReportInterval   string `json:"report_interval"`
-	CryptoKey        string `json:"crypto_key"`
-	GrpcAddr         string `json:"grpc_addr"`
+	MetricServerAddr string            `json:"address"`
+	PollInterval     string            `json:"poll_interval"`
+	ReportInterval   string            `json:"report_interval"`
+	CryptoKey        string            `json:"crypto_key"`
+	GrpcAddr         string            `json:"grpc_addr"`
+	Token            string            `json:"token"`
+	Servers          []Server          `json:"servers"`
+	FanOut           bool              `json:"fan_out"`
+	HealthCheck      string            `json:"health_check_interval"`
+	SpoolDir         string            `json:"spool_dir"`
+	SpoolMaxSize     int64             `json:"spool_max_size"`
+	RequestTimeout   string            `json:"request_timeout"`
+	ShutdownTimeout  string            `json:"shutdown_timeout"`
+	DebugAddr        string            `json:"debug_addr"`
+	Hostname         string            `json:"hostname"`
+	InstanceID       string            `json:"instance_id"`
+	Tags             map[string]string `json:"tags"`
+	Retry            struct {
+		Attempts       int     `json:"attempts"`
+		InitialBackoff string  `json:"initial_backoff"`
+		MaxBackoff     string  `json:"max_backoff"`
+		Multiplier     float64 `json:"multiplier"`
+		Jitter         float64 `json:"jitter"`
+	} `json:"retry"`
+	Protocol string `json:"protocol"`
+	Change   struct {
+		Enabled   bool    `json:"enabled"`
+		Absolute  float64 `json:"absolute"`
+		Relative  float64 `json:"relative"`
+		Heartbeat string  `json:"heartbeat"`
+	} `json:"push_on_change"`
+	HTTP struct {
+		MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host"`
+		IdleConnTimeout     string `json:"idle_conn_timeout"`
+		HTTP2               bool   `json:"http2"`
+	} `json:"http"`
+	Batch struct {
+		MaxCount int `json:"max_count"`
+		MaxBytes int `json:"max_bytes"`
+	} `json:"batch"`
+	RemoteConfigInterval string                   `json:"remote_config_interval"`
+	Collectors           map[string]CollectorFile `json:"collectors"`
+	Disk                 DiskFile                 `json:"disk"`
+	Network              NetworkFile              `json:"network"`
+	Processes            []Process                `json:"processes"`
+	Scripts              []struct {
+		Name     string   `json:"name"`
+		Command  string   `json:"command"`
+		Args     []string `json:"args"`
+		Interval string   `json:"interval"`
+		Timeout  string   `json:"timeout"`
+		Format   string   `json:"format"`
+	} `json:"scripts"`
+	Scrape []struct {
+		Name     string `json:"name"`
+		URL      string `json:"url"`
+		Interval string `json:"interval"`
+		Timeout  string `json:"timeout"`
+	} `json:"scrape"`
+}
+
+// CollectorFile настройки коллектора в файле конфигурации.
+type CollectorFile struct {
+	Enabled      *bool  `json:"enabled"`
+	PollInterval string `json:"poll_interval"`
+	Aggregate    bool   `json:"aggregate"`
+}
+
+// DiskFile настройки коллектора дисков в файле конфигурации.
+type DiskFile struct {
+	IncludeMounts  []string `json:"include_mounts"`
+	ExcludeMounts  []string `json:"exclude_mounts"`
+	IncludeFSTypes []string `json:"include_fs_types"`
+	ExcludeFSTypes []string `json:"exclude_fs_types"`
+}
+
+// NetworkFile настройки коллектора сетевых интерфейсов в файле конфигурации.
+type NetworkFile struct {
+	IncludeInterfaces []string `json:"include_interfaces"`
+	ExcludeInterfaces []string `json:"exclude_interfaces"`
+	TCPStates         *bool    `json:"tcp_states"`
+}
+
+// Remote конфигурация, которую агент получает с сервера.
+// Поля совпадают с одноимёнными полями файла конфигурации, незаданные поля не меняют текущие настройки.
+type Remote struct {
+	PollInterval   string                   `json:"poll_interval"`
+	ReportInterval string                   `json:"report_interval"`
+	Collectors     map[string]CollectorFile `json:"collectors"`
+	Disk           *DiskFile                `json:"disk"`
+	Network        *NetworkFile             `json:"network"`
+}
+
+// Server адреса резервного сервера метрик.
+type Server struct {
+	Addr     string `json:"address"`
+	GrpcAddr string `json:"grpc_addr"`
 }
 
 type Config struct {
@@ -33,6 +153,190 @@ type Config struct {
 	RateLimit        int
 	CryptoKey        string
 	ServerGrpcAddr   string
+	Token            string
+	// все серверы метрик в порядке приоритета, первый - основной.
+	Servers []Server
+	// отправлять метрики на все доступные серверы, а не только на первый.
+	FanOut              bool
+	HealthCheckInterval time.Duration
+	// каталог очереди неотправленных батчей, если пустой - очередь отключена.
+	SpoolDir string
+	// максимальный размер очереди в байтах.
+	SpoolMaxSize int64
+	// таймаут одного запроса к серверу.
+	RequestTimeout time.Duration
+	// время на финальную отправку метрик при остановке агента.
+	ShutdownTimeout time.Duration
+	Retry           Retry
+	Batch           Batch
+	// отправка gauge-метрик только при изменении.
+	Change Change
+	HTTP   HTTP
+	// протокол отправки метрик: http или grpc.
+	Protocol string
+	// адрес локального HTTP сервера с телеметрией агента, если пустой - сервер не запускается.
+	DebugAddr string
+	// интервал запроса конфигурации с сервера, если нулевой - удалённая конфигурация не используется.
+	RemoteConfigInterval time.Duration
+	// имя хоста в метке host, по умолчанию имя хоста из ОС.
+	Hostname string
+	// идентификатор экземпляра агента в метке instance, если пустой - метка не добавляется.
+	InstanceID string
+	// статические метки, добавляемые ко всем метрикам агента.
+	Tags map[string]string
+	// настройки коллекторов по имени коллектора.
+	Collectors map[string]Collector
+	Disk       Disk
+	Network    Network
+	// отслеживаемые процессы.
+	Processes []Process
+	// команды, вывод которых разбирается в метрики.
+	Scripts []Script
+	// адреса метрик в формате Prometheus.
+	Scrape []Scrape
+}
+
+// Scrape адрес метрик в текстовом формате Prometheus.
+type Scrape struct {
+	Name string
+	URL  string
+	// интервал опроса, если нулевой - используется интервал коллектора prometheus.
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// Script команда коллектора exec.
+type Script struct {
+	Name    string
+	Command string
+	Args    []string
+	// интервал запуска, если нулевой - используется интервал коллектора exec.
+	Interval time.Duration
+	Timeout  time.Duration
+	// формат вывода: text, json или пустая строка для автоопределения.
+	Format string
+}
+
+// Process отслеживаемый процесс, выбирается по pid-файлу или по имени и командной строке.
+type Process struct {
+	// значение метки process в метриках.
+	Label string `json:"label"`
+	// точное имя исполняемого файла.
+	Name string `json:"name"`
+	// путь к файлу с pid процесса.
+	PidFile string `json:"pidfile"`
+	// регулярное выражение для командной строки.
+	Cmdline string `json:"cmdline"`
+}
+
+// Network настройки коллектора сетевых интерфейсов.
+type Network struct {
+	// фильтр по имени интерфейса.
+	Interfaces Filter
+	// собирать число TCP-соединений по состояниям.
+	TCPStates bool
+}
+
+// Disk отбор разделов для коллектора дисков.
+type Disk struct {
+	// фильтр по точке монтирования.
+	Mounts Filter
+	// фильтр по типу файловой системы.
+	FSTypes Filter
+}
+
+// Filter включающие и исключающие шаблоны в формате path.Match.
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// Collector настройки коллектора метрик.
+type Collector struct {
+	Enabled bool
+	// интервал опроса, если нулевой - используется PollInterval.
+	PollInterval time.Duration
+	// отправлять агрегаты значений за интервал отправки отдельными метриками.
+	Aggregate bool
+}
+
+// Collector возвращает настройки коллектора name,
+// по умолчанию коллектор включён и опрашивается с интервалом PollInterval.
+func (c Config) Collector(name string) Collector {
+	res, ok := c.Collectors[name]
+	if !ok {
+		res = Collector{Enabled: true}
+	}
+	if res.PollInterval == 0 {
+		res.PollInterval = c.PollInterval
+	}
+
+	return res
+}
+
+// HTTP настройки соединений HTTP транспорта.
+type HTTP struct {
+	// максимальное число простаивающих соединений HTTP/1.1 с одним сервером.
+	MaxIdleConnsPerHost int
+	// время, после которого простаивающее соединение HTTP/1.1 закрывается.
+	IdleConnTimeout time.Duration
+	// отправлять метрики по HTTP/2 без TLS (h2c), сервер должен поддерживать h2c.
+	HTTP2 bool
+}
+
+// Change настройки отправки метрик только при изменении.
+// Gauge-метрика отправляется, если её значение изменилось больше порогов Absolute и Relative,
+// counter-метрика - если её приращение не нулевое. Нулевые пороги не ограничивают изменение.
+// Раз в Heartbeat отправляются все метрики.
+type Change struct {
+	Enabled bool
+	// минимальное абсолютное изменение значения.
+	Absolute float64
+	// минимальное изменение как доля модуля последнего отправленного значения.
+	Relative float64
+	// интервал полной отправки всех метрик.
+	Heartbeat time.Duration
+}
+
+// Batch ограничения размера одного батча метрик.
+type Batch struct {
+	// максимальное количество метрик.
+	MaxCount int
+	// максимальный размер батча в JSON до сжатия и шифрования.
+	MaxBytes int
+}
+
+// Retry политика повторной отправки метрик.
+type Retry struct {
+	// количество попыток, включая первую.
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// множитель задержки для каждой следующей попытки.
+	Multiplier float64
+	// доля случайного отклонения задержки, от 0 до 1.
+	Jitter float64
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию без адресов серверов.
+func Default() Config {
+	return Config{
+		HealthCheckInterval: healthCheckInterval,
+		SpoolMaxSize:        spoolMaxSize,
+		RequestTimeout:      requestTimeout,
+		ShutdownTimeout:     shutdownTimeout,
+		Retry: Retry{
+			Attempts:       retryAttempts,
+			InitialBackoff: retryInitialBackoff,
+			MaxBackoff:     retryMaxBackoff,
+			Multiplier:     retryMultiplier,
+			Jitter:         retryJitter,
+		},
+		Batch:    Batch{MaxCount: batchMaxCount, MaxBytes: batchMaxBytes},
+		Change:   Change{Heartbeat: changeHeartbeat},
+		HTTP:     HTTP{MaxIdleConnsPerHost: httpMaxIdleConnsPerHost, IdleConnTimeout: httpIdleConnTimeout},
+		Protocol: "http",
+	}
 }
 
 func New(log zerolog.Logger) (Config, error) {
@@ -46,6 +350,20 @@ func New(log zerolog.Logger) (Config, error) {
 	rLimit := flag.Int("l", rateLimit, "pull worker")
 	cryptoKey := flag.String("crypto-key", "internal/agent/config/pbkey.pem", "crypto key for agent")
 	grpc := flag.String("g", "127.0.0.1:8083", "grpc address")
+	token := flag.String("token", "", "agent api token")
+	servers := flag.String("servers", "", "reserve servers: address;grpc_addr, comma separated")
+	fanOut := flag.Bool("fan-out", false, "send metrics to all servers")
+	spoolDir := flag.String("spool-dir", "", "directory for unsent batches")
+	attempts := flag.Int("retry-attempts", 0, "send attempts")
+	protocol := flag.String("protocol", "", "send protocol: http or grpc")
+	disabled := flag.String("disable-collectors", "", "disabled collectors, comma separated")
+	debugAddr := flag.String("debug-addr", "", "local address for agent telemetry")
+	hostname := flag.String("hostname", "", "host label value")
+	instanceID := flag.String("instance-id", "", "instance label value")
+	tags := flag.String("tags", "", "static tags: key=value, comma separated")
+	remoteInterval := flag.Int("remote-config-interval", 0, "remote config poll interval, seconds")
+	http2 := flag.Bool("http2", false, "send metrics over HTTP/2 without TLS")
+	pushOnChange := flag.Bool("push-on-change", false, "send gauges only when they change")
 	flag.Parse()
 
 	var cfgPathName string
@@ -160,5 +478,515 @@ func New(log zerolog.Logger) (Config, error) {
 		}
 	}
 
+	tokenEnv, ok := os.LookupEnv("TOKEN")
+	if ok {
+		cfg.Token = tokenEnv
+	} else {
+		cfg.Token = *token
+		if cfg.Token == "" {
+			cfg.Token = cfgFileData.Token
+		}
+	}
+
+	cfg.Servers = []Server{{Addr: cfg.MetricServerAddr, GrpcAddr: cfg.ServerGrpcAddr}}
+	serversEnv, ok := os.LookupEnv("SERVERS")
+	if ok {
+		cfg.Servers = append(cfg.Servers, parseServers(serversEnv)...)
+	} else {
+		reserve := parseServers(*servers)
+		if len(reserve) == 0 {
+			reserve = cfgFileData.Servers
+		}
+		cfg.Servers = append(cfg.Servers, reserve...)
+	}
+
+	fanOutEnv, ok := os.LookupEnv("FAN_OUT")
+	if ok {
+		fO, err := strconv.ParseBool(fanOutEnv)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.ParseBool: %w", err)
+		}
+
+		cfg.FanOut = fO
+	} else {
+		cfg.FanOut = *fanOut || cfgFileData.FanOut
+	}
+
+	cfg.HealthCheckInterval = healthCheckInterval
+	if cfgFileData.HealthCheck != "" {
+		hC, err := time.ParseDuration(cfgFileData.HealthCheck)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.HealthCheckInterval = hC
+	}
+
+	spoolDirEnv, ok := os.LookupEnv("SPOOL_DIR")
+	if ok {
+		cfg.SpoolDir = spoolDirEnv
+	} else {
+		cfg.SpoolDir = *spoolDir
+		if cfg.SpoolDir == "" {
+			cfg.SpoolDir = cfgFileData.SpoolDir
+		}
+	}
+
+	cfg.SpoolMaxSize = spoolMaxSize
+	spoolMaxSizeEnv, ok := os.LookupEnv("SPOOL_MAX_SIZE")
+	if ok {
+		sM, err := strconv.ParseInt(spoolMaxSizeEnv, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.ParseInt: %w", err)
+		}
+
+		cfg.SpoolMaxSize = sM
+	} else if cfgFileData.SpoolMaxSize != 0 {
+		cfg.SpoolMaxSize = cfgFileData.SpoolMaxSize
+	}
+
+	cfg.RequestTimeout = requestTimeout
+	if cfgFileData.RequestTimeout != "" {
+		rT, err := time.ParseDuration(cfgFileData.RequestTimeout)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.RequestTimeout = rT
+	}
+
+	cfg.ShutdownTimeout = shutdownTimeout
+	if cfgFileData.ShutdownTimeout != "" {
+		sT, err := time.ParseDuration(cfgFileData.ShutdownTimeout)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.ShutdownTimeout = sT
+	}
+
+	cfg.Retry = Retry{
+		Attempts:       retryAttempts,
+		InitialBackoff: retryInitialBackoff,
+		MaxBackoff:     retryMaxBackoff,
+		Multiplier:     retryMultiplier,
+		Jitter:         retryJitter,
+	}
+	retryAttemptsEnv, ok := os.LookupEnv("RETRY_ATTEMPTS")
+	if ok {
+		rA, err := strconv.Atoi(retryAttemptsEnv)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.Atoi: %w", err)
+		}
+
+		cfg.Retry.Attempts = rA
+	} else if *attempts != 0 {
+		cfg.Retry.Attempts = *attempts
+	} else if cfgFileData.Retry.Attempts != 0 {
+		cfg.Retry.Attempts = cfgFileData.Retry.Attempts
+	}
+	if cfgFileData.Retry.InitialBackoff != "" {
+		iB, err := time.ParseDuration(cfgFileData.Retry.InitialBackoff)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.Retry.InitialBackoff = iB
+	}
+	if cfgFileData.Retry.MaxBackoff != "" {
+		mB, err := time.ParseDuration(cfgFileData.Retry.MaxBackoff)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.Retry.MaxBackoff = mB
+	}
+	if cfgFileData.Retry.Multiplier != 0 {
+		cfg.Retry.Multiplier = cfgFileData.Retry.Multiplier
+	}
+	if cfgFileData.Retry.Jitter != 0 {
+		cfg.Retry.Jitter = cfgFileData.Retry.Jitter
+	}
+
+	cfg.Batch = Batch{MaxCount: batchMaxCount, MaxBytes: batchMaxBytes}
+	batchMaxCountEnv, ok := os.LookupEnv("BATCH_MAX_COUNT")
+	if ok {
+		bC, err := strconv.Atoi(batchMaxCountEnv)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.Atoi: %w", err)
+		}
+
+		cfg.Batch.MaxCount = bC
+	} else if cfgFileData.Batch.MaxCount != 0 {
+		cfg.Batch.MaxCount = cfgFileData.Batch.MaxCount
+	}
+
+	batchMaxBytesEnv, ok := os.LookupEnv("BATCH_MAX_BYTES")
+	if ok {
+		bB, err := strconv.Atoi(batchMaxBytesEnv)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.Atoi: %w", err)
+		}
+
+		cfg.Batch.MaxBytes = bB
+	} else if cfgFileData.Batch.MaxBytes != 0 {
+		cfg.Batch.MaxBytes = cfgFileData.Batch.MaxBytes
+	}
+
+	cfg.Change = Change{
+		Absolute:  cfgFileData.Change.Absolute,
+		Relative:  cfgFileData.Change.Relative,
+		Heartbeat: changeHeartbeat,
+	}
+	pushOnChangeEnv, ok := os.LookupEnv("PUSH_ON_CHANGE")
+	if ok {
+		pC, err := strconv.ParseBool(pushOnChangeEnv)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.ParseBool: %w", err)
+		}
+
+		cfg.Change.Enabled = pC
+	} else {
+		cfg.Change.Enabled = *pushOnChange || cfgFileData.Change.Enabled
+	}
+	if cfgFileData.Change.Heartbeat != "" {
+		h, err := time.ParseDuration(cfgFileData.Change.Heartbeat)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.Change.Heartbeat = h
+	}
+
+	cfg.HTTP = HTTP{MaxIdleConnsPerHost: httpMaxIdleConnsPerHost, IdleConnTimeout: httpIdleConnTimeout}
+	if cfgFileData.HTTP.MaxIdleConnsPerHost != 0 {
+		cfg.HTTP.MaxIdleConnsPerHost = cfgFileData.HTTP.MaxIdleConnsPerHost
+	}
+	if cfgFileData.HTTP.IdleConnTimeout != "" {
+		iC, err := time.ParseDuration(cfgFileData.HTTP.IdleConnTimeout)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.HTTP.IdleConnTimeout = iC
+	}
+	http2Env, ok := os.LookupEnv("HTTP2")
+	if ok {
+		h2, err := strconv.ParseBool(http2Env)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.ParseBool: %w", err)
+		}
+
+		cfg.HTTP.HTTP2 = h2
+	} else {
+		cfg.HTTP.HTTP2 = *http2 || cfgFileData.HTTP.HTTP2
+	}
+
+	protocolEnv, ok := os.LookupEnv("PROTOCOL")
+	if ok {
+		cfg.Protocol = protocolEnv
+	} else {
+		cfg.Protocol = *protocol
+		if cfg.Protocol == "" {
+			cfg.Protocol = cfgFileData.Protocol
+		}
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = "http"
+	}
+
+	debugAddrEnv, ok := os.LookupEnv("DEBUG_ADDR")
+	if ok {
+		cfg.DebugAddr = debugAddrEnv
+	} else {
+		cfg.DebugAddr = *debugAddr
+		if cfg.DebugAddr == "" {
+			cfg.DebugAddr = cfgFileData.DebugAddr
+		}
+	}
+
+	hostnameEnv, ok := os.LookupEnv("AGENT_HOSTNAME")
+	if ok {
+		cfg.Hostname = hostnameEnv
+	} else {
+		cfg.Hostname = *hostname
+		if cfg.Hostname == "" {
+			cfg.Hostname = cfgFileData.Hostname
+		}
+	}
+	if cfg.Hostname == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			l.Err(err).Msg("os.Hostname")
+		}
+
+		cfg.Hostname = h
+	}
+
+	instanceIDEnv, ok := os.LookupEnv("INSTANCE_ID")
+	if ok {
+		cfg.InstanceID = instanceIDEnv
+	} else {
+		cfg.InstanceID = *instanceID
+		if cfg.InstanceID == "" {
+			cfg.InstanceID = cfgFileData.InstanceID
+		}
+	}
+
+	tagsEnv, ok := os.LookupEnv("TAGS")
+	if ok {
+		cfg.Tags = parseTags(tagsEnv)
+	} else {
+		cfg.Tags = parseTags(*tags)
+		if len(cfg.Tags) == 0 {
+			cfg.Tags = cfgFileData.Tags
+		}
+	}
+
+	remoteIntervalEnv, ok := os.LookupEnv("REMOTE_CONFIG_INTERVAL")
+	if ok {
+		rI, err := strconv.Atoi(remoteIntervalEnv)
+		if err != nil {
+			return Config{}, fmt.Errorf("strconv.Atoi: %w", err)
+		}
+
+		cfg.RemoteConfigInterval = time.Duration(rI) * time.Second
+	} else {
+		cfg.RemoteConfigInterval = time.Duration(*remoteInterval) * time.Second
+		if cfg.RemoteConfigInterval == 0 && cfgFileData.RemoteConfigInterval != "" {
+			rI, err := time.ParseDuration(cfgFileData.RemoteConfigInterval)
+			if err != nil {
+				return Config{}, err
+			}
+
+			cfg.RemoteConfigInterval = rI
+		}
+	}
+
+	cfg.Collectors = make(map[string]Collector, len(cfgFileData.Collectors))
+	for name, v := range cfgFileData.Collectors {
+		collector, err := v.collector()
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.Collectors[name] = collector
+	}
+
+	disabledEnv, ok := os.LookupEnv("DISABLE_COLLECTORS")
+	if !ok {
+		disabledEnv = *disabled
+	}
+	for _, name := range strings.Split(disabledEnv, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		collector := cfg.Collectors[name]
+		collector.Enabled = false
+		cfg.Collectors[name] = collector
+	}
+
+	cfg.Disk = cfgFileData.Disk.disk()
+	cfg.Network = cfgFileData.Network.network()
+
+	cfg.Processes = cfgFileData.Processes
+
+	for _, v := range cfgFileData.Scripts {
+		script := Script{
+			Name:    v.Name,
+			Command: v.Command,
+			Args:    v.Args,
+			Timeout: scriptTimeout,
+			Format:  v.Format,
+		}
+		if v.Interval != "" {
+			i, err := time.ParseDuration(v.Interval)
+			if err != nil {
+				return Config{}, err
+			}
+
+			script.Interval = i
+		}
+		if v.Timeout != "" {
+			t, err := time.ParseDuration(v.Timeout)
+			if err != nil {
+				return Config{}, err
+			}
+
+			script.Timeout = t
+		}
+
+		cfg.Scripts = append(cfg.Scripts, script)
+	}
+
+	for _, v := range cfgFileData.Scrape {
+		scrape := Scrape{
+			Name:    v.Name,
+			URL:     v.URL,
+			Timeout: scrapeTimeout,
+		}
+		if v.Interval != "" {
+			i, err := time.ParseDuration(v.Interval)
+			if err != nil {
+				return Config{}, err
+			}
+
+			scrape.Interval = i
+		}
+		if v.Timeout != "" {
+			t, err := time.ParseDuration(v.Timeout)
+			if err != nil {
+				return Config{}, err
+			}
+
+			scrape.Timeout = t
+		}
+
+		cfg.Scrape = append(cfg.Scrape, scrape)
+	}
+
 	return cfg, nil
 }
+
+// parseServers разбирает список серверов в формате address;grpc_addr через запятую.
+func parseServers(s string) []Server {
+	var res []Server
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		addr, grpcAddr, _ := strings.Cut(v, ";")
+		res = append(res, Server{Addr: strings.TrimSpace(addr), GrpcAddr: strings.TrimSpace(grpcAddr)})
+	}
+
+	return res
+}
+
+// parseTags разбирает метки в формате key=value через запятую, пары без ключа пропускаются.
+func parseTags(s string) map[string]string {
+	res := make(map[string]string)
+	for _, v := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		res[key] = strings.TrimSpace(value)
+	}
+
+	return res
+}
+
+// Identity возвращает метки, которые агент добавляет ко всем метрикам:
+// статические метки Tags, имя хоста host и идентификатор экземпляра instance.
+// host и instance имеют приоритет над одноимёнными статическими метками.
+func (c Config) Identity() map[string]string {
+	res := make(map[string]string, len(c.Tags)+2)
+	for k, v := range c.Tags {
+		res[k] = v
+	}
+	if c.Hostname != "" {
+		res["host"] = c.Hostname
+	}
+	if c.InstanceID != "" {
+		res["instance"] = c.InstanceID
+	}
+
+	return res
+}
+
+func (f CollectorFile) collector() (Collector, error) {
+	res := Collector{Enabled: true, Aggregate: f.Aggregate}
+	if f.Enabled != nil {
+		res.Enabled = *f.Enabled
+	}
+	if f.PollInterval != "" {
+		pI, err := time.ParseDuration(f.PollInterval)
+		if err != nil {
+			return Collector{}, err
+		}
+
+		res.PollInterval = pI
+	}
+
+	return res, nil
+}
+
+func (f DiskFile) disk() Disk {
+	return Disk{
+		Mounts:  Filter{Include: f.IncludeMounts, Exclude: f.ExcludeMounts},
+		FSTypes: Filter{Include: f.IncludeFSTypes, Exclude: f.ExcludeFSTypes},
+	}
+}
+
+func (f NetworkFile) network() Network {
+	res := Network{
+		Interfaces: Filter{Include: f.IncludeInterfaces, Exclude: f.ExcludeInterfaces},
+		TCPStates:  true,
+	}
+	if f.TCPStates != nil {
+		res.TCPStates = *f.TCPStates
+	}
+
+	return res
+}
+
+// WithRemote возвращает копию конфигурации с применённой удалённой конфигурацией data в формате Remote.
+// Настройки коллекторов из удалённой конфигурации заменяют локальные настройки тех же коллекторов.
+func (c Config) WithRemote(data []byte) (Config, error) {
+	var remote Remote
+	if err := json.Unmarshal(data, &remote); err != nil {
+		return Config{}, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	if remote.PollInterval != "" {
+		pI, err := time.ParseDuration(remote.PollInterval)
+		if err != nil {
+			return Config{}, fmt.Errorf("poll_interval: %w", err)
+		}
+
+		c.PollInterval = pI
+	}
+	if remote.ReportInterval != "" {
+		rI, err := time.ParseDuration(remote.ReportInterval)
+		if err != nil {
+			return Config{}, fmt.Errorf("report_interval: %w", err)
+		}
+
+		c.ReportInterval = rI
+	}
+	if c.PollInterval <= 0 || c.ReportInterval <= 0 {
+		return Config{}, ErrInvalidInterval
+	}
+
+	collectors := make(map[string]Collector, len(c.Collectors)+len(remote.Collectors))
+	for name, v := range c.Collectors {
+		collectors[name] = v
+	}
+	for name, v := range remote.Collectors {
+		collector, err := v.collector()
+		if err != nil {
+			return Config{}, fmt.Errorf("collectors.%s: %w", name, err)
+		}
+		if collector.PollInterval < 0 {
+			return Config{}, fmt.Errorf("collectors.%s: %w", name, ErrInvalidInterval)
+		}
+
+		collectors[name] = collector
+	}
+	c.Collectors = collectors
+
+	if remote.Disk != nil {
+		c.Disk = remote.Disk.disk()
+	}
+	if remote.Network != nil {
+		c.Network = remote.Network.network()
+	}
+
+	return c, nil
+}
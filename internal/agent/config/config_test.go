@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -42,3 +43,153 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_Collector(t *testing.T) {
+	cfg := Config{
+		PollInterval: 2 * time.Second,
+		Collectors: map[string]Collector{
+			"disabled": {Enabled: false},
+			"fast":     {Enabled: true, PollInterval: time.Second},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want Collector
+	}{
+		{
+			name: "runtime",
+			want: Collector{Enabled: true, PollInterval: 2 * time.Second},
+		},
+		{
+			name: "disabled",
+			want: Collector{Enabled: false, PollInterval: 2 * time.Second},
+		},
+		{
+			name: "fast",
+			want: Collector{Enabled: true, PollInterval: time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.Collector(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Collector() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_WithRemote(t *testing.T) {
+	cfg := Config{
+		PollInterval:   2 * time.Second,
+		ReportInterval: 10 * time.Second,
+		Collectors:     map[string]Collector{"memory": {Enabled: false}},
+		Network:        Network{TCPStates: true},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    Config
+		wantErr error
+	}{
+		{
+			name: "empty",
+			data: `{}`,
+			want: cfg,
+		},
+		{
+			name: "intervals and collectors",
+			data: `{"poll_interval":"1s","report_interval":"1m","collectors":{"cpu":{"enabled":false,"poll_interval":"5s"}}}`,
+			want: Config{
+				PollInterval:   time.Second,
+				ReportInterval: time.Minute,
+				Collectors: map[string]Collector{
+					"memory": {Enabled: false},
+					"cpu":    {Enabled: false, PollInterval: 5 * time.Second},
+				},
+				Network: Network{TCPStates: true},
+			},
+		},
+		{
+			name: "filters",
+			data: `{"disk":{"include_mounts":["/"]},"network":{"exclude_interfaces":["lo"],"tcp_states":false}}`,
+			want: Config{
+				PollInterval:   2 * time.Second,
+				ReportInterval: 10 * time.Second,
+				Collectors:     map[string]Collector{"memory": {Enabled: false}},
+				Disk:           Disk{Mounts: Filter{Include: []string{"/"}}},
+				Network:        Network{Interfaces: Filter{Exclude: []string{"lo"}}},
+			},
+		},
+		{
+			name:    "invalid interval",
+			data:    `{"report_interval":"0s"}`,
+			wantErr: ErrInvalidInterval,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.WithRemote([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithRemote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithRemote() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := cfg.WithRemote([]byte(`[]`)); err == nil {
+		t.Error("WithRemote() expected error for non-object config")
+	}
+	if _, ok := cfg.Collectors["cpu"]; ok {
+		t.Error("WithRemote() must not change the local config")
+	}
+}
+
+func TestConfig_Identity(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want map[string]string
+	}{
+		{
+			name: "host only",
+			cfg:  Config{Hostname: "web-1"},
+			want: map[string]string{"host": "web-1"},
+		},
+		{
+			name: "instance and tags",
+			cfg: Config{
+				Hostname:   "web-1",
+				InstanceID: "a1",
+				Tags:       map[string]string{"env": "prod", "host": "ignored"},
+			},
+			want: map[string]string{"host": "web-1", "instance": "a1", "env": "prod"},
+		},
+		{
+			name: "empty",
+			cfg:  Config{},
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Identity(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Identity() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTags(t *testing.T) {
+	got := parseTags(" env=prod, dc = eu-1,=skip,,role")
+	want := map[string]string{"env": "prod", "dc": "eu-1", "role": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTags() got = %v, want %v", got, want)
+	}
+}
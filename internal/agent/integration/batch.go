@@ -0,0 +1,40 @@
+package integration
+
+import (
+	"encoding/json"
+)
+
+// splitBatches делит метрики на батчи не больше maxCount штук и maxBytes байт в JSON.
+// Нулевое ограничение не применяется, метрика больше maxBytes отправляется отдельным батчем.
+func splitBatches(metrics []MetricsRequest, maxCount, maxBytes int) [][]MetricsRequest {
+	var batches [][]MetricsRequest
+	var batch []MetricsRequest
+	// размер пустого массива "[]".
+	size := 2
+
+	for _, v := range metrics {
+		data, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		// элемент и разделяющая запятая.
+		itemSize := len(data) + 1
+
+		full := len(batch) != 0 &&
+			((maxCount > 0 && len(batch) >= maxCount) || (maxBytes > 0 && size+itemSize > maxBytes))
+		if full {
+			batches = append(batches, batch)
+			batch = nil
+			size = 2
+		}
+
+		batch = append(batch, v)
+		size += itemSize
+	}
+
+	if len(batch) != 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBatches(t *testing.T) {
+	metrics := make([]MetricsRequest, 0, 10)
+	for i := 0; i < 10; i++ {
+		metrics = append(metrics, toRequest(metric.NewGauge(fmt.Sprintf("m%d", i), 1)))
+	}
+	item, err := json.Marshal(metrics[0])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		maxCount int
+		maxBytes int
+		want     []int
+	}{
+		{name: "without limits", want: []int{10}},
+		{name: "by count", maxCount: 4, want: []int{4, 4, 2}},
+		{name: "by bytes", maxBytes: 2 + 3*(len(item)+1), want: []int{3, 3, 3, 1}},
+		{name: "by count and bytes", maxCount: 2, maxBytes: 2 + 3*(len(item)+1), want: []int{2, 2, 2, 2, 2}},
+		{name: "metric larger than limit", maxBytes: 1, want: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := splitBatches(metrics, tt.maxCount, tt.maxBytes)
+
+			got := make([]int, 0, len(batches))
+			var total []MetricsRequest
+			for _, v := range batches {
+				got = append(got, len(v))
+				total = append(total, v...)
+
+				if tt.maxBytes > len(item)+2 {
+					data, err := json.Marshal(v)
+					require.NoError(t, err)
+					assert.LessOrEqual(t, len(data), tt.maxBytes)
+				}
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, metrics, total)
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	data := make([]byte, 1000)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+
+	encrypted, err := encrypt(&key.PublicKey, data)
+	require.NoError(t, err)
+	require.Zero(t, len(encrypted)%key.Size())
+
+	var decrypted []byte
+	for start := 0; start < len(encrypted); start += key.Size() {
+		block, err := rsa.DecryptPKCS1v15(rand.Reader, key, encrypted[start:start+key.Size()])
+		require.NoError(t, err)
+		decrypted = append(decrypted, block...)
+	}
+
+	assert.Equal(t, data, decrypted)
+}
+
+func TestSnapshot_Requests(t *testing.T) {
+	got := snapshot{
+		metrics: []metric.Metric{
+			metric.NewGauge("Alloc", 1.5),
+			metric.NewCounter("Requests", 3),
+		},
+		pollCount: 7,
+		timestamp: time.Now(),
+	}.requests()
+
+	require.Len(t, got, 3)
+
+	assert.Equal(t, "Alloc", got[0].ID)
+	assert.Equal(t, "gauge", got[0].MType)
+	require.NotNil(t, got[0].Value)
+	assert.Equal(t, 1.5, *got[0].Value)
+	assert.Nil(t, got[0].Delta)
+
+	assert.Equal(t, "Requests", got[1].ID)
+	assert.Equal(t, "counter", got[1].MType)
+	require.NotNil(t, got[1].Delta)
+	assert.Equal(t, int64(3), *got[1].Delta)
+	assert.Nil(t, got[1].Value)
+
+	assert.Equal(t, "PollCount", got[2].ID)
+	assert.Equal(t, "counter", got[2].MType)
+	require.NotNil(t, got[2].Delta)
+	assert.Equal(t, int64(7), *got[2].Delta)
+
+	data, err := json.Marshal(got[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"Alloc","type":"gauge","value":1.5}`, string(data))
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"math"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+)
+
+// changeFilter отбирает для отправки только изменившиеся метрики.
+// Фильтр не потокобезопасен, снимки для отправки создаются последовательно.
+type changeFilter struct {
+	cfg config.Change
+	// последние отправленные значения gauge-метрик по ID.
+	last     map[string]float64
+	lastFull time.Time
+}
+
+func newChangeFilter(cfg config.Change) *changeFilter {
+	return &changeFilter{
+		cfg:  cfg,
+		last: make(map[string]float64),
+	}
+}
+
+// filter возвращает метрики для отправки в момент now.
+// Если с последней полной отправки прошло больше Heartbeat, возвращаются все метрики.
+func (f *changeFilter) filter(metrics []metric.Metric, now time.Time) []metric.Metric {
+	full := now.Sub(f.lastFull) >= f.cfg.Heartbeat
+	if full {
+		f.lastFull = now
+	}
+
+	res := make([]metric.Metric, 0, len(metrics))
+	for _, v := range metrics {
+		if v.Kind == metric.KindCounter {
+			if full || v.Delta != 0 {
+				res = append(res, v)
+			}
+			continue
+		}
+
+		id := v.ID()
+		prev, ok := f.last[id]
+		if !full && ok && !f.changed(prev, v.Value) {
+			continue
+		}
+
+		f.last[id] = v.Value
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// changed сообщает, превышает ли изменение значения все заданные пороги.
+func (f *changeFilter) changed(prev, cur float64) bool {
+	if prev == cur || math.IsNaN(prev) && math.IsNaN(cur) {
+		return false
+	}
+
+	diff := math.Abs(cur - prev)
+	if f.cfg.Absolute > 0 && diff < f.cfg.Absolute {
+		return false
+	}
+	if f.cfg.Relative > 0 && diff < f.cfg.Relative*math.Abs(prev) {
+		return false
+	}
+
+	return true
+}
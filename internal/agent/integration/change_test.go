@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(metrics []metric.Metric) []string {
+	res := make([]string, 0, len(metrics))
+	for _, v := range metrics {
+		res = append(res, v.ID())
+	}
+
+	return res
+}
+
+func TestChangeFilter_changed(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Change
+		prev float64
+		cur  float64
+		want bool
+	}{
+		{name: "same value", prev: 10, cur: 10, want: false},
+		{name: "any change", prev: 10, cur: 10.001, want: true},
+		{name: "below absolute", cfg: config.Change{Absolute: 5}, prev: 10, cur: 14, want: false},
+		{name: "above absolute", cfg: config.Change{Absolute: 5}, prev: 10, cur: 4, want: true},
+		{name: "below relative", cfg: config.Change{Relative: 0.1}, prev: 1000, cur: 1050, want: false},
+		{name: "above relative", cfg: config.Change{Relative: 0.1}, prev: 1000, cur: 1200, want: true},
+		{name: "relative from zero", cfg: config.Change{Relative: 0.1}, prev: 0, cur: 1, want: true},
+		{
+			name: "above relative below absolute",
+			cfg:  config.Change{Absolute: 100, Relative: 0.1},
+			prev: 10, cur: 20,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChangeFilter(tt.cfg)
+			assert.Equal(t, tt.want, f.changed(tt.prev, tt.cur))
+		})
+	}
+}
+
+func TestChangeFilter_filter(t *testing.T) {
+	f := newChangeFilter(config.Change{Absolute: 1, Heartbeat: time.Minute})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// первая отправка полная.
+	got := f.filter([]metric.Metric{
+		metric.NewGauge("Alloc", 100),
+		metric.NewGauge("Load1", 0.5),
+		metric.NewCounter("Requests", 0),
+	}, now)
+	assert.Equal(t, []string{"Alloc", "Load1", "Requests"}, ids(got))
+
+	now = now.Add(10 * time.Second)
+	got = f.filter([]metric.Metric{
+		metric.NewGauge("Alloc", 100.5),
+		metric.NewGauge("Load1", 2),
+		metric.NewGauge("HeapAlloc", 1),
+		metric.NewCounter("Requests", 0),
+		metric.NewCounter("Errors", 3),
+	}, now)
+	assert.Equal(t, []string{"Load1", "HeapAlloc", "Errors"}, ids(got))
+
+	// изменение считается от последнего отправленного значения, а не от последнего снятого.
+	now = now.Add(10 * time.Second)
+	got = f.filter([]metric.Metric{
+		metric.NewGauge("Alloc", 101),
+		metric.NewGauge("Load1", 2),
+	}, now)
+	assert.Equal(t, []string{"Alloc"}, ids(got))
+
+	// по истечении Heartbeat отправляются все метрики.
+	now = now.Add(time.Minute)
+	got = f.filter([]metric.Metric{
+		metric.NewGauge("Alloc", 101),
+		metric.NewGauge("Load1", 2),
+		metric.NewCounter("Requests", 0),
+	}, now)
+	assert.Equal(t, []string{"Alloc", "Load1", "Requests"}, ids(got))
+}
+
+func TestChangeFilter_labels(t *testing.T) {
+	f := newChangeFilter(config.Change{Heartbeat: time.Hour})
+	now := time.Now()
+
+	disk := metric.NewGauge("DiskFree", 10)
+	f.filter([]metric.Metric{
+		disk.WithLabels(map[string]string{"mount": "/"}),
+		disk.WithLabels(map[string]string{"mount": "/data"}),
+	}, now)
+
+	got := f.filter([]metric.Metric{
+		disk.WithLabels(map[string]string{"mount": "/"}),
+		metric.NewGauge("DiskFree", 20).WithLabels(map[string]string{"mount": "/data"}),
+	}, now.Add(time.Second))
+	assert.Equal(t, []string{`DiskFree{mount="/data"}`}, ids(got))
+}
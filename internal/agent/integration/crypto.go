@@ -0,0 +1,58 @@
+package integration
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// pkcs1v15Overhead служебные байты дополнения PKCS #1 v1.5 в каждом блоке.
+const pkcs1v15Overhead = 11
+
+var (
+	ErrInvalidKey = errors.New("failed to decode PEM block")
+)
+
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParsePKCS1PublicKey: %w", err)
+	}
+
+	return publicKey, nil
+}
+
+// encrypt шифрует данные публичным ключом блоками, каждый блок шифротекста равен размеру ключа.
+func encrypt(publicKey *rsa.PublicKey, data []byte) ([]byte, error) {
+	chunk := publicKey.Size() - pkcs1v15Overhead
+	res := make([]byte, 0, (len(data)/chunk+1)*publicKey.Size())
+
+	for start := 0; start < len(data); start += chunk {
+		end := start + chunk
+		if end > len(data) {
+			end = len(data)
+		}
+
+		block, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, data[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("rsa.EncryptPKCS1v15: %w", err)
+		}
+		res = append(res, block...)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	proto "github.com/1Asi1/metric-track.git/rpc/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// grpcTransport отправляет батчи методом Updates с подписью и адресом агента в метаданных.
+type grpcTransport struct {
+	client proto.MetricGrpcClient
+	conn   *grpc.ClientConn
+	cfg    config.Config
+	realIP string
+}
+
+func newGRPCTransport(cfg config.Config, addr, realIP string) (*grpcTransport, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("grpc.Dial: %w", err)
+	}
+
+	return &grpcTransport{
+		client: proto.NewMetricGrpcClient(conn),
+		conn:   conn,
+		cfg:    cfg,
+		realIP: realIP,
+	}, nil
+}
+
+// FetchConfig получает конфигурацию агента методом AgentConfig.
+func (t *grpcTransport) FetchConfig(ctx context.Context, version string) ([]byte, string, error) {
+	ctx = metadata.NewOutgoingContext(ctx, t.metadata())
+
+	resp, err := t.client.AgentConfig(ctx, &proto.AgentConfigRequest{Version: version})
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.NotModified {
+		return nil, version, ErrConfigNotModified
+	}
+
+	return resp.Config, resp.Version, nil
+}
+
+func (t *grpcTransport) Send(ctx context.Context, metrics []MetricsRequest) error {
+	req := &proto.UpdatesRequest{Metrics: make([]*proto.Metric, 0, len(metrics))}
+	for _, v := range metrics {
+		req.Metrics = append(req.Metrics, toProto(v))
+	}
+
+	md := t.metadata()
+	if t.cfg.SecretKey != "" {
+		data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("proto.Marshal: %w", err)
+		}
+		md.Set("HashSHA256", sign(t.cfg.SecretKey, data))
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	if _, err := t.client.Updates(ctx, req); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// metadata возвращает токен и адрес агента для метаданных запроса.
+func (t *grpcTransport) metadata() metadata.MD {
+	md := metadata.MD{}
+	if t.cfg.Token != "" {
+		md.Set("authorization", "Bearer "+t.cfg.Token)
+	}
+	if t.realIP != "" {
+		md.Set("x-real-ip", t.realIP)
+	}
+
+	return md
+}
+
+func (t *grpcTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+
+	return t.conn.Close()
+}
+
+// toProto переводит метрику в сообщение gRPC.
+func toProto(m MetricsRequest) *proto.Metric {
+	res := &proto.Metric{ID: m.ID, MType: m.MType, Tags: m.Tags}
+	if m.Value != nil {
+		res.Value = *m.Value
+	}
+	if m.Delta != nil {
+		res.Delta = *m.Delta
+	}
+
+	return res
+}
@@ -0,0 +1,209 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	servergrpc "github.com/1Asi1/metric-track.git/internal/server/transport/grpc"
+	proto "github.com/1Asi1/metric-track.git/rpc/gen"
+	metricmock "github.com/1Asi1/metric-track.git/rpc/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func TestGRPCTransport_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := metricmock.NewMockMetricGrpcClient(ctrl)
+
+	cfg := config.Config{SecretKey: "secret", Token: "token"}
+	tr := &grpcTransport{client: client, cfg: cfg, realIP: "192.168.1.10"}
+
+	metrics := []MetricsRequest{
+		toRequest(metric.NewGauge("Alloc", 1024)),
+		toRequest(metric.NewCounter("PollCount", 5)),
+	}
+	metrics[0].Tags = map[string]string{"host": "web-1"}
+
+	client.EXPECT().
+		Updates(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *proto.UpdatesRequest, opts ...grpc.CallOption) (*proto.UpdatesResponse, error) {
+			require.Len(t, req.Metrics, 2)
+			assert.Equal(t, "Alloc", req.Metrics[0].ID)
+			assert.Equal(t, "gauge", req.Metrics[0].MType)
+			assert.Equal(t, float64(1024), req.Metrics[0].Value)
+			assert.Equal(t, map[string]string{"host": "web-1"}, req.Metrics[0].Tags)
+			assert.Equal(t, "PollCount", req.Metrics[1].ID)
+			assert.Equal(t, int64(5), req.Metrics[1].Delta)
+
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+			assert.Equal(t, []string{"192.168.1.10"}, md.Get("x-real-ip"))
+
+			data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(req)
+			require.NoError(t, err)
+			assert.Equal(t, []string{sign("secret", data)}, md.Get("HashSHA256"))
+
+			return &proto.UpdatesResponse{}, nil
+		})
+
+	require.NoError(t, tr.Send(context.Background(), metrics))
+}
+
+func TestGRPCTransport_SendTagsHMAC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := metricmock.NewMockMetricGrpcClient(ctrl)
+
+	tr := &grpcTransport{client: client, cfg: config.Config{SecretKey: "secret"}}
+
+	metrics := make([]MetricsRequest, 0, 40)
+	for i := 0; i < 40; i++ {
+		m := toRequest(metric.NewGauge(fmt.Sprintf("Gauge%d", i), float64(i)))
+		m.Tags = map[string]string{"host": "web-1", "instance": "agent-1", "env": "prod"}
+		metrics = append(metrics, m)
+	}
+
+	verify := servergrpc.HMACInterceptor("secret")
+	info := &grpc.UnaryServerInfo{FullMethod: proto.MetricGrpc_Updates_FullMethodName}
+
+	client.EXPECT().
+		Updates(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *proto.UpdatesRequest, opts ...grpc.CallOption) (*proto.UpdatesResponse, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+
+			// сервер получает запрос по сети и декодирует его заново.
+			data, err := protobuf.Marshal(req)
+			require.NoError(t, err)
+			received := &proto.UpdatesRequest{}
+			require.NoError(t, protobuf.Unmarshal(data, received))
+
+			_, err = verify(metadata.NewIncomingContext(context.Background(), md), received, info,
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return &proto.UpdatesResponse{}, nil
+				})
+
+			return &proto.UpdatesResponse{}, err
+		}).
+		Times(20)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, tr.Send(context.Background(), metrics))
+	}
+}
+
+func TestGRPCTransport_SendError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := metricmock.NewMockMetricGrpcClient(ctrl)
+	tr := &grpcTransport{client: client}
+
+	client.EXPECT().
+		Updates(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.Unavailable, "server restarting"))
+
+	err := tr.Send(context.Background(), []MetricsRequest{toRequest(metric.NewGauge("Alloc", 1))})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	retry, _ := retryable(err)
+	assert.True(t, retry)
+}
+
+func TestGRPCTransport_FetchConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := metricmock.NewMockMetricGrpcClient(ctrl)
+	tr := &grpcTransport{client: client, cfg: config.Config{Token: "token"}}
+
+	client.EXPECT().
+		AgentConfig(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *proto.AgentConfigRequest, opts ...grpc.CallOption) (*proto.AgentConfigResponse, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+
+			if req.Version == "v1" {
+				return &proto.AgentConfigResponse{Version: "v1", NotModified: true}, nil
+			}
+
+			return &proto.AgentConfigResponse{Version: "v1", Config: []byte(`{}`)}, nil
+		}).
+		Times(2)
+
+	data, version, err := tr.FetchConfig(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", version)
+	assert.Equal(t, []byte(`{}`), data)
+
+	_, _, err = tr.FetchConfig(context.Background(), version)
+	assert.ErrorIs(t, err, ErrConfigNotModified)
+}
+
+func TestNewTransport(t *testing.T) {
+	key := newPublicKey(t)
+
+	tests := []struct {
+		name     string
+		protocol string
+		key      string
+		server   config.Server
+		want     any
+		realIP   string
+		wantErr  bool
+	}{
+		{name: "default", key: key, server: config.Server{Addr: "localhost:8080"}, want: &httpTransport{}},
+		{name: "http", protocol: ProtocolHTTP, key: key, server: config.Server{Addr: "localhost:8080"}, want: &httpTransport{}},
+		{name: "http without key", protocol: ProtocolHTTP, server: config.Server{Addr: "localhost:8080"}, wantErr: true},
+		{
+			name:     "grpc",
+			protocol: ProtocolGRPC,
+			server:   config.Server{Addr: "localhost:8080", GrpcAddr: "localhost:8083"},
+			want:     &grpcTransport{},
+		},
+		{
+			name:   "http real ip",
+			key:    key,
+			server: config.Server{Addr: "127.0.0.1:8080"},
+			want:   &httpTransport{},
+			realIP: "127.0.0.1",
+		},
+		{
+			name:     "grpc real ip",
+			protocol: ProtocolGRPC,
+			server:   config.Server{Addr: "localhost", GrpcAddr: "127.0.0.1:8083"},
+			want:     &grpcTransport{},
+			realIP:   "127.0.0.1",
+		},
+		{name: "grpc without address", protocol: ProtocolGRPC, server: config.Server{Addr: "localhost:8080"}, wantErr: true},
+		{name: "unknown", protocol: "udp", server: config.Server{Addr: "localhost:8080"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTransport(config.Config{Protocol: tt.protocol, CryptoKey: tt.key}, tt.server, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, got)
+			if tt.realIP != "" {
+				// адрес определяется по маршруту до того сервера, к которому относится транспорт.
+				switch tr := got.(type) {
+				case *httpTransport:
+					assert.Equal(t, tt.realIP, tr.realIP)
+				case *grpcTransport:
+					assert.Equal(t, tt.realIP, tr.realIP)
+				}
+			}
+			assert.NoError(t, got.Close())
+		})
+	}
+}
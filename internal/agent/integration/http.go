@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/net/http2"
+)
+
+// httpTransport отправляет батчи на /updates/ сжатыми, зашифрованными и подписанными.
+// Соединения с сервером переиспользуются между отправками через пул HTTP клиента.
+type httpTransport struct {
+	client *resty.Client
+	cfg    config.Config
+	url    string
+	// адрес удалённой конфигурации агента.
+	configURL string
+	realIP    string
+	// публичный ключ разбирается один раз при создании транспорта.
+	publicKey *rsa.PublicKey
+}
+
+func newHTTPTransport(cfg config.Config, addr string, client *resty.Client, realIP string) (*httpTransport, error) {
+	publicKey, err := readPublicKey(cfg.CryptoKey)
+	if err != nil {
+		return nil, fmt.Errorf("readPublicKey: %w", err)
+	}
+
+	return &httpTransport{
+		client:    client,
+		cfg:       cfg,
+		url:       fmt.Sprintf("http://%s/updates/", addr),
+		configURL: fmt.Sprintf("http://%s/agent/config", addr),
+		realIP:    realIP,
+		publicKey: publicKey,
+	}, nil
+}
+
+// newHTTPClient создаёт HTTP клиент, общий для всех серверов.
+func newHTTPClient(cfg config.Config) *resty.Client {
+	client := resty.New()
+	client.SetTimeout(cfg.RequestTimeout)
+	client.SetTransport(newRoundTripper(cfg.HTTP))
+
+	return client
+}
+
+// newRoundTripper возвращает транспорт с пулом соединений HTTP/1.1 или,
+// если включён HTTP2, транспорт HTTP/2 без TLS, где все запросы к серверу идут по одному соединению.
+func newRoundTripper(cfg config.HTTP) http.RoundTripper {
+	if cfg.HTTP2 {
+		return &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+
+	return transport
+}
+
+var (
+	bufPool  = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+	gzipPool = sync.Pool{New: func() any {
+		gz, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return gz
+	}}
+)
+
+func (t *httpTransport) Send(ctx context.Context, metrics []MetricsRequest) error {
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+
+	encrypteData, err := encrypt(t.publicKey, data)
+	if err != nil {
+		return err
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+	if err = compress(buf, encrypteData); err != nil {
+		return err
+	}
+
+	request := t.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Content-Encoding", "gzip").
+		SetHeader("HashSHA256", sign(t.cfg.SecretKey, buf.Bytes())).
+		SetBody(buf.Bytes())
+	if t.realIP != "" {
+		request.SetHeader("X-Real-IP", t.realIP)
+	}
+	if t.cfg.Token != "" {
+		request.SetAuthToken(t.cfg.Token)
+	}
+
+	resp, err := request.Post(t.url)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.RawBody().Close() }()
+
+	if resp.StatusCode() != http.StatusOK {
+		return &StatusError{
+			Code:       resp.StatusCode(),
+			RetryAfter: parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()),
+		}
+	}
+
+	return nil
+}
+
+// FetchConfig получает конфигурацию агента с /agent/config, версия передаётся в If-None-Match.
+func (t *httpTransport) FetchConfig(ctx context.Context, version string) ([]byte, string, error) {
+	request := t.client.R().SetContext(ctx)
+	if version != "" {
+		request.SetHeader("If-None-Match", strconv.Quote(version))
+	}
+	if t.realIP != "" {
+		request.SetHeader("X-Real-IP", t.realIP)
+	}
+	if t.cfg.Token != "" {
+		request.SetAuthToken(t.cfg.Token)
+	}
+
+	resp, err := request.Get(t.configURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, version, ErrConfigNotModified
+	default:
+		return nil, "", &StatusError{Code: resp.StatusCode()}
+	}
+
+	newVersion, err := strconv.Unquote(resp.Header().Get("ETag"))
+	if err != nil {
+		return nil, "", fmt.Errorf("strconv.Unquote: %w", err)
+	}
+
+	return resp.Body(), newVersion, nil
+}
+
+func (t *httpTransport) Close() error {
+	return nil
+}
+
+// compress сжимает данные gzip в buf.
+func compress(buf *bytes.Buffer, data []byte) error {
+	gz := gzipPool.Get().(*gzip.Writer)
+	defer gzipPool.Put(gz)
+
+	gz.Reset(buf)
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// sign возвращает подпись HMAC-SHA256 данных в hex.
+func sign(secretKey string, data []byte) string {
+	h := hmac.New(sha256.New, []byte(secretKey))
+	_, _ = h.Write(data)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// connServer тестовый сервер /updates/, считающий новые соединения и версии протокола запросов.
+type connServer struct {
+	*httptest.Server
+	conns  atomic.Int64
+	proto2 atomic.Int64
+}
+
+func newConnServer() *connServer {
+	cs := &connServer{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 {
+			cs.proto2.Add(1)
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cs.Server = httptest.NewUnstartedServer(h2c.NewHandler(handler, &http2.Server{}))
+	cs.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			cs.conns.Add(1)
+		}
+	}
+	cs.Start()
+
+	return cs
+}
+
+func newBenchTransport(tb testing.TB, cfg config.Config, addr string, client *resty.Client) *httpTransport {
+	tb.Helper()
+
+	tr, err := newHTTPTransport(cfg, addr, client, "")
+	require.NoError(tb, err)
+
+	return tr
+}
+
+func testBatch(n int) []MetricsRequest {
+	metrics := make([]metric.Metric, 0, n)
+	for i := 0; i < n; i++ {
+		metrics = append(metrics, metric.NewGauge(fmt.Sprintf("Metric%d", i), float64(i)))
+	}
+
+	return toRequests(metrics)
+}
+
+func TestHTTPTransport_KeepAlive(t *testing.T) {
+	tests := []struct {
+		name       string
+		http2      bool
+		wantProto2 int64
+	}{
+		{name: "http1"},
+		{name: "h2c", http2: true, wantProto2: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newConnServer()
+			defer ts.Close()
+
+			cfg := config.Default()
+			cfg.CryptoKey = newPublicKey(t)
+			cfg.HTTP.HTTP2 = tt.http2
+
+			client := newHTTPClient(cfg)
+			defer client.GetClient().CloseIdleConnections()
+			tr := newBenchTransport(t, cfg, strings.TrimPrefix(ts.URL, "http://"), client)
+
+			for i := 0; i < 10; i++ {
+				require.NoError(t, tr.Send(context.Background(), testBatch(10)))
+			}
+
+			assert.Equal(t, int64(1), ts.conns.Load())
+			assert.Equal(t, tt.wantProto2, ts.proto2.Load())
+		})
+	}
+}
+
+// BenchmarkHTTPTransport_Send сравнивает прежнюю отправку, которая закрывала соединение
+// и читала ключ при каждой отправке, с пулом соединений HTTP/1.1 и HTTP/2 без TLS.
+func BenchmarkHTTPTransport_Send(b *testing.B) {
+	ts := newConnServer()
+	defer ts.Close()
+
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	cfg := config.Default()
+	cfg.CryptoKey = newPublicKey(b)
+	batch := testBatch(100)
+
+	b.Run("close-connection", func(b *testing.B) {
+		client := resty.New()
+		client.SetCloseConnection(true)
+
+		for i := 0; i < b.N; i++ {
+			tr := newBenchTransport(b, cfg, addr, client)
+			if err := tr.Send(context.Background(), batch); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "batches/s")
+	})
+
+	for _, v := range []struct {
+		name  string
+		http2 bool
+	}{
+		{name: "keep-alive"},
+		{name: "h2c", http2: true},
+	} {
+		b.Run(v.name, func(b *testing.B) {
+			cfg := cfg
+			cfg.HTTP.HTTP2 = v.http2
+			client := newHTTPClient(cfg)
+			defer client.GetClient().CloseIdleConnections()
+			tr := newBenchTransport(b, cfg, addr, client)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := tr.Send(context.Background(), batch); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "batches/s")
+		})
+	}
+}
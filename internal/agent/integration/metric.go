@@ -1,230 +1,403 @@
 package integration
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
-	"crypto/hmac"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
-	"crypto/x509"
-	"encoding/hex"
-	"encoding/json"
-	"encoding/pem"
+	"errors"
 	"fmt"
-	random "math/rand"
-	"net/http"
-	"os"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/1Asi1/metric-track.git/internal/agent/config"
-	"github.com/1Asi1/metric-track.git/internal/agent/service"
-	proto "github.com/1Asi1/metric-track.git/rpc/gen"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/1Asi1/metric-track.git/internal/agent/spool"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
 	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type MetricsRequest struct {
-	MType string `json:"type"`
-	Delta any    `json:"delta"`
-	Value any    `json:"value"`
-	ID    string `json:"id"`
+	MType string   `json:"type"`
+	Delta *int64   `json:"delta,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+	ID    string   `json:"id"`
+	// метки агента: host, instance и статические метки из конфигурации.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Source источник метрик для периодической отправки.
+type Source interface {
+	// Start запускает сбор метрик до отмены ctx.
+	Start(ctx context.Context)
+	// GetMetric возвращает метрики для очередной отправки.
+	GetMetric() []metric.Metric
 }
 
 type Client struct {
-	service service.Service
+	service Source
 	http    *resty.Client
 	log     zerolog.Logger
 	cfg     config.Config
-	grpc    proto.MetricGrpcClient
-}
+	servers []*server
+	spool   *spool.Spool
+	retry   retryPolicy
+	// replaying выставлен, пока выполняется воспроизведение очереди.
+	replaying atomic.Bool
+	// метки, добавляемые ко всем отправляемым метрикам.
+	tags map[string]string
+	// фильтр неизменившихся метрик, nil если отправляются все метрики.
+	change *changeFilter
+	// телеметрия агента, может быть nil.
+	telemetry *telemetry.Telemetry
+	// интервал отправки, меняется удалённой конфигурацией.
+	reportInterval *interval
+	// версия последней применённой удалённой конфигурации.
+	configVersion string
 
-func New(cfg config.Config, s service.Service, log zerolog.Logger) *Client {
-	client := resty.New()
-	client.SetTimeout(10 * time.Second)
-
-	contentConnDialCtx, contentConnDialCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer contentConnDialCancel()
+	// cancel останавливает сбор метрик, проверки серверов и незавершённые отправки.
+	cancel context.CancelFunc
+	// stopCancel прерывает запросы конфигурации и воспроизведение очереди при вызове Stop.
+	stopCancel context.CancelFunc
+	stop       chan struct{}
+	job        chan batch
+	loops      sync.WaitGroup
+	workers    sync.WaitGroup
+	stopOnce   sync.Once
+}
 
-	clientConn, err := grpc.DialContext(contentConnDialCtx, cfg.ServerGrpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Err(err).Msgf("grpc.Dial error: %v", err)
+// New создаёт клиента агента. Сервер, для которого не удалось создать транспорт, пропускается.
+func New(cfg config.Config, s Source, tel *telemetry.Telemetry, log zerolog.Logger) *Client {
+	c, errs := newClient(cfg, s, tel, log)
+	for _, err := range errs {
+		log.Err(err).Msg("newTransport")
 	}
 
-	grpcClient := proto.NewMetricGrpcClient(clientConn)
-	return &Client{
-		cfg:     cfg,
-		service: s,
-		http:    client,
-		log:     log,
-		grpc:    grpcClient,
-	}
-}
-
-func (c *Client) SendMetricPeriodic(ctx context.Context) {
-	l := c.log.With().Str("integration", "SendMetricPeriodic").Logger()
-
-	var count int
-	var res service.Metric
-	res = c.service.GetMetric()
-
-	tickerPool := time.NewTicker(c.cfg.PollInterval)
-	tickerRep := time.NewTicker(c.cfg.ReportInterval)
-
-	type data map[string]any
-	job := make(chan data, len(res.Type))
-	counter := make(chan int, len(res.Type))
-	for i := 0; i < c.cfg.RateLimit; i++ {
-		go func(job chan data, counter chan int) {
-			for j := range job {
-				ct := <-counter
-				if err := c.sendToServerBatch(ctx, j, ct); err != nil {
-					l.Error().Err(err).Msgf("c.sendToServerBatch")
-					return
-				}
-				if err := c.sendToServerBatchGrpc(ctx, j, ct); err != nil {
-					l.Error().Err(err).Msgf("c.sendToServerBatchGrpc")
-					return
-				}
-			}
-		}(job, counter)
+	return c
+}
+
+// NewClient создаёт клиента так же, как New, но возвращает ошибку,
+// если транспорт хотя бы одного сервера не удалось создать.
+func NewClient(cfg config.Config, s Source, tel *telemetry.Telemetry, log zerolog.Logger) (*Client, error) {
+	c, errs := newClient(cfg, s, tel, log)
+	if len(errs) != 0 {
+		return nil, errors.Join(append(errs, c.Close())...)
 	}
 
-	go func() {
-		for {
-			select {
-			case <-tickerPool.C:
-				res = c.service.GetMetric()
-				count++
-				res.Type["RandomValue"] = random.ExpFloat64()
-			case <-tickerRep.C:
-				for k, v := range res.Type {
-					req := make(data, 1)
-					req[k] = v
-					job <- req
-					counter <- count
-				}
-				count = 0
-			}
-		}
-	}()
+	return c, nil
 }
 
-func (c *Client) sendToServerBatch(ctx context.Context, req map[string]any, count int) error {
-	metrics := make([]MetricsRequest, 2)
-	for k, v := range req {
-		metrics[0] = MetricsRequest{
-			ID:    k,
-			MType: "gauge",
-			Value: v,
-			Delta: nil,
+func newClient(cfg config.Config, s Source, tel *telemetry.Telemetry, log zerolog.Logger) (*Client, []error) {
+	client := newHTTPClient(cfg)
+
+	var errs []error
+	servers := make([]*server, 0, len(cfg.Servers))
+	for _, v := range cfg.Servers {
+		transport, err := newTransport(cfg, v, client)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("server %s: %w", v.Addr, err))
+			continue
 		}
 
-		metrics[1] = MetricsRequest{
-			ID:    k,
-			MType: "counter",
-			Delta: count,
-			Value: nil,
+		servers = append(servers, newServer(v, transport))
+	}
+
+	var sp *spool.Spool
+	if cfg.SpoolDir != "" {
+		var err error
+		sp, err = spool.New(cfg.SpoolDir, cfg.SpoolMaxSize)
+		if err != nil {
+			log.Err(err).Msgf("spool.New, spool dir: %s", cfg.SpoolDir)
 		}
 	}
 
-	url := fmt.Sprintf("http://%s/updates/", c.cfg.MetricServerAddr)
+	if sp != nil {
+		tel.Gauge("SpoolBatches", func() float64 { return float64(sp.Len()) })
+		tel.Gauge("SpoolBytes", func() float64 { return float64(sp.Size()) })
+		tel.Gauge("SpoolDropped", func() float64 { return float64(sp.Dropped()) })
+	}
 
-	data, err := json.Marshal(metrics)
-	if err != nil {
-		return err
+	var change *changeFilter
+	if cfg.Change.Enabled {
+		change = newChangeFilter(cfg.Change)
 	}
 
-	file, err := os.OpenFile(c.cfg.CryptoKey, os.O_RDONLY, 0644)
+	return &Client{
+		cfg:       cfg,
+		service:   s,
+		http:      client,
+		log:       log,
+		servers:   servers,
+		spool:     sp,
+		retry:     newRetryPolicy(cfg.Retry),
+		tags:      cfg.Identity(),
+		change:    change,
+		telemetry: tel,
+
+		reportInterval: newInterval(cfg.ReportInterval),
+	}, errs
+}
+
+// outboundIP возвращает адрес интерфейса, через который агент обращается к серверу.
+func outboundIP(addr string) string {
+	conn, err := net.Dial("udp", addr)
 	if err != nil {
-		panic(err)
+		return ""
 	}
-	defer func() { _ = file.Close() }()
+	defer func() { _ = conn.Close() }()
 
-	key := make([]byte, 1024)
-	_, err = file.Read(key)
+	host, _, err := net.SplitHostPort(conn.LocalAddr().String())
 	if err != nil {
-		return err
+		return ""
 	}
 
-	block, _ := pem.Decode(key)
-	publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
-	if err != nil {
-		return err
+	return host
+}
+
+// Start запускает сбор и периодическую отправку метрик до вызова Stop.
+// Отмена ctx останавливает агента без финальной отправки.
+func (c *Client) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.stop = make(chan struct{})
+	stopCtx, stopCancel := context.WithCancel(ctx)
+	c.stopCancel = stopCancel
+
+	c.service.Start(ctx)
+
+	go c.healthCheckPeriodic(ctx)
+
+	workers := c.cfg.RateLimit
+	if workers < 1 {
+		workers = 1
 	}
-	encrypteData, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, data)
-	if err != nil {
-		return err
+
+	c.job = make(chan batch, workers)
+	job := c.job
+	c.telemetry.Gauge("QueueDepth", func() float64 { return float64(len(job)) })
+	for i := 0; i < workers; i++ {
+		c.workers.Add(1)
+		go c.worker(ctx)
 	}
 
-	var buf bytes.Buffer
-	gz, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
-	if err != nil {
-		return err
+	c.loops.Add(1)
+	go c.reportLoop(ctx, stopCtx)
+
+	if c.cfg.RemoteConfigInterval > 0 {
+		c.loops.Add(1)
+		go c.configLoop(stopCtx)
 	}
-	defer func() { _ = gz.Close() }()
-	_, err = gz.Write(encrypteData)
-	if err != nil {
-		return err
+}
+
+// Stop останавливает опрос, отправляет последний снимок метрик и дожидается отправки
+// уже поставленных в очередь снимков, после чего закрывает соединения с серверами.
+// Если ctx истекает раньше, незавершённые отправки прерываются.
+// Stop без предшествующего Start только закрывает соединения.
+func (c *Client) Stop(ctx context.Context) error {
+	var err error
+	c.stopOnce.Do(func() {
+		if c.stop == nil {
+			err = c.Close()
+			return
+		}
+		err = c.shutdown(ctx)
+	})
+
+	return err
+}
+
+func (c *Client) shutdown(ctx context.Context) error {
+	close(c.stop)
+	c.stopCancel()
+
+	var errs []error
+	if err := wait(ctx, &c.loops); err != nil {
+		errs = append(errs, fmt.Errorf("loops: %w", err))
+		c.cancel()
+		c.loops.Wait()
+	}
+
+	if err := c.enqueue(ctx, c.takeSnapshot()); err != nil {
+		errs = append(errs, fmt.Errorf("final report: %w", err))
 	}
-	_ = gz.Close()
+	close(c.job)
 
-	request := c.http.R().
-		SetHeader("Content-Type", "application/json").
-		SetHeader("X-Real-IP", c.http.BaseURL)
-	request.SetContext(ctx)
-	request.SetHeader("Content-Encoding", "gzip")
+	if err := wait(ctx, &c.workers); err != nil {
+		errs = append(errs, fmt.Errorf("drain: %w", err))
+	}
 
-	request.SetBody(&buf)
-	request.Method = resty.MethodPost
-	request.URL = url
-	defer c.http.SetCloseConnection(true)
+	c.cancel()
+	c.workers.Wait()
 
-	h1 := hmac.New(sha256.New, []byte(c.cfg.SecretKey))
-	_, err = h1.Write(buf.Bytes())
-	if err != nil {
-		return err
+	return errors.Join(append(errs, c.Close())...)
+}
+
+// wait дожидается wg, пока не истёк ctx.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	res := hex.EncodeToString(h1.Sum(nil))
-	request.SetHeader("HashSHA256", res)
+}
 
-	//зашифровать байты публичным ключем
-	resp, err := request.Send()
-	if err != nil {
-		return err
+func (c *Client) worker(ctx context.Context) {
+	defer c.workers.Done()
+
+	l := c.log.With().Str("integration", "worker").Logger()
+
+	for {
+		var b batch
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-c.job:
+			if !ok {
+				return
+			}
+			b = v
+		}
+
+		if err := c.deliver(ctx, b.requests); err != nil {
+			l.Error().Err(err).Msgf("c.deliver, poll count: %d, snapshot: %s",
+				b.pollCount, b.timestamp.Format(time.RFC3339))
+		}
 	}
-	defer func() { _ = resp.RawBody().Close() }()
+}
 
-	if resp.StatusCode() != http.StatusOK {
-		return fmt.Errorf("expected status %d, got: %d", http.StatusOK, resp.StatusCode())
+// enqueue делит снимок на батчи и ставит их в очередь воркеров.
+// Батчи одного снимка отправляются воркерами параллельно.
+func (c *Client) enqueue(ctx context.Context, s snapshot) error {
+	for _, v := range c.batches(s) {
+		select {
+		case c.job <- v:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	return nil
 }
 
-func (c *Client) sendToServerBatchGrpc(ctx context.Context, req map[string]any, count int) error {
-	metrics := make([]*proto.Metric, 2)
-	for k, v := range req {
-		metrics[0] = &proto.Metric{
-			ID:    k,
-			MType: "gauge",
-			Value: v.(float64),
+// reportLoop ставит снимки в очередь воркеров, stopCtx отменяется при вызове Stop
+// и прерывает воспроизведение очереди на диске.
+func (c *Client) reportLoop(ctx, stopCtx context.Context) {
+	defer c.loops.Done()
+
+	ticker := time.NewTicker(c.reportInterval.get())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stop:
+			return
+		case <-c.reportInterval.reset:
+			ticker.Reset(c.reportInterval.get())
+		case <-ticker.C:
+			c.startReplay(stopCtx)
+
+			if err := c.enqueue(ctx, c.takeSnapshot()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ReportError ошибка Report, часть батчей которого не доставлена.
+type ReportError struct {
+	// метрики недоставленных батчей.
+	Failed []MetricsRequest
+	Err    error
+}
+
+func (e *ReportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReportError) Unwrap() error {
+	return e.Err
+}
+
+// Report отправляет метрики батчами так же, как периодическая отправка, но без счётчика опросов PollCount.
+// Если часть батчей не доставлена, возвращается *ReportError с их метриками.
+func (c *Client) Report(ctx context.Context, metrics []metric.Metric) error {
+	res := &ReportError{}
+	var errs []error
+	for _, v := range splitBatches(c.tag(toRequests(metrics)), c.cfg.Batch.MaxCount, c.cfg.Batch.MaxBytes) {
+		if err := c.deliver(ctx, v); err != nil {
+			errs = append(errs, err)
+			res.Failed = append(res.Failed, v...)
 		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	res.Err = errors.Join(errs...)
+
+	return res
+}
 
-		metrics[1] = &proto.Metric{
-			ID:    k,
-			MType: "counter",
-			Delta: int64(count),
+// Close закрывает соединения со всеми серверами.
+func (c *Client) Close() error {
+	if c.http != nil {
+		c.http.GetClient().CloseIdleConnections()
+	}
+
+	var errs []error
+	for _, v := range c.servers {
+		if err := v.transport.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.addr, err))
 		}
 	}
 
-	_, err := c.grpc.Updates(ctx, &proto.UpdatesRequest{Metrics: []*proto.Metric{}}, nil)
-	if err != nil {
-		return err
+	return errors.Join(errs...)
+}
+
+func toRequests(metrics []metric.Metric) []MetricsRequest {
+	res := make([]MetricsRequest, 0, len(metrics)+1)
+	for _, v := range metrics {
+		res = append(res, toRequest(v))
 	}
 
-	return nil
+	return res
+}
+
+// tag добавляет метки агента к запросам.
+func (c *Client) tag(reqs []MetricsRequest) []MetricsRequest {
+	if len(c.tags) == 0 {
+		return reqs
+	}
+
+	for i := range reqs {
+		reqs[i].Tags = c.tags
+	}
+
+	return reqs
+}
+
+// toRequest переводит метрику в формат запроса к серверу согласно её типу.
+func toRequest(m metric.Metric) MetricsRequest {
+	req := MetricsRequest{
+		ID:    m.ID(),
+		MType: string(m.Kind),
+	}
+
+	switch m.Kind {
+	case metric.KindCounter:
+		delta := m.Delta
+		req.Delta = &delta
+	case metric.KindGauge:
+		value := m.Value
+		req.Value = &value
+	}
+
+	return req
 }
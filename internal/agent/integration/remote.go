@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+)
+
+// Reconfigurable источник метрик, к которому можно применить новую конфигурацию без перезапуска.
+type Reconfigurable interface {
+	Apply(cfg config.Config) error
+}
+
+// interval интервал тикера, который можно изменить во время работы цикла.
+type interval struct {
+	d     atomic.Int64
+	reset chan struct{}
+}
+
+func newInterval(d time.Duration) *interval {
+	iv := &interval{reset: make(chan struct{}, 1)}
+	iv.d.Store(int64(d))
+
+	return iv
+}
+
+func (iv *interval) get() time.Duration {
+	return time.Duration(iv.d.Load())
+}
+
+// set меняет интервал и сообщает об этом циклу, если интервал изменился.
+func (iv *interval) set(d time.Duration) {
+	if time.Duration(iv.d.Swap(int64(d))) == d {
+		return
+	}
+
+	select {
+	case iv.reset <- struct{}{}:
+	default:
+	}
+}
+
+// configLoop периодически запрашивает удалённую конфигурацию и применяет её новые версии.
+func (c *Client) configLoop(ctx context.Context) {
+	defer c.loops.Done()
+
+	l := c.log.With().Str("integration", "configLoop").Logger()
+
+	ticker := time.NewTicker(c.cfg.RemoteConfigInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.syncConfig(ctx); err != nil && !errors.Is(err, ErrConfigNotModified) {
+			l.Warn().Err(err).Msg("c.syncConfig")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncConfig получает конфигурацию с первого ответившего сервера и применяет её, если версия изменилась.
+func (c *Client) syncConfig(ctx context.Context) error {
+	data, version, err := c.fetchConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = c.applyConfig(data); err != nil {
+		return fmt.Errorf("c.applyConfig, version %s: %w", version, err)
+	}
+
+	c.configVersion = version
+	c.log.Info().Msgf("remote config %s applied", version)
+
+	return nil
+}
+
+// fetchConfig запрашивает конфигурацию сначала у доступных серверов, затем у остальных.
+func (c *Client) fetchConfig(ctx context.Context) ([]byte, string, error) {
+	if len(c.servers) == 0 {
+		return nil, "", ErrNoServers
+	}
+
+	var errs []error
+	for _, srv := range c.ordered() {
+		fetcher, ok := srv.transport.(ConfigFetcher)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %w", srv.addr, ErrConfigNotSupported))
+			continue
+		}
+
+		data, version, err := fetcher.FetchConfig(ctx, c.configVersion)
+		if err == nil || errors.Is(err, ErrConfigNotModified) {
+			return data, version, err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", srv.addr, err))
+	}
+
+	return nil, "", errors.Join(errs...)
+}
+
+// applyConfig применяет удалённую конфигурацию data поверх локальной конфигурации агента.
+func (c *Client) applyConfig(data []byte) error {
+	cfg, err := c.cfg.WithRemote(data)
+	if err != nil {
+		return fmt.Errorf("c.cfg.WithRemote: %w", err)
+	}
+
+	if source, ok := c.service.(Reconfigurable); ok {
+		if err = source.Apply(cfg); err != nil {
+			return fmt.Errorf("source.Apply: %w", err)
+		}
+	}
+
+	c.reportInterval.set(cfg.ReportInterval)
+
+	return nil
+}
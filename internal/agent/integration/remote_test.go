@@ -0,0 +1,232 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configServer тестовый сервер /agent/config с текущей конфигурацией и её версией.
+type configServer struct {
+	*httptest.Server
+	hits atomic.Int64
+
+	mu      sync.Mutex
+	data    string
+	version string
+}
+
+func newConfigServer(data, version string) *configServer {
+	cs := &configServer{data: data, version: version}
+	cs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cs.hits.Add(1)
+
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+
+		if r.URL.Path != "/agent/config" || cs.version == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		etag := strconv.Quote(cs.version)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		_, _ = w.Write([]byte(cs.data))
+	}))
+
+	return cs
+}
+
+func (cs *configServer) set(data, version string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.data, cs.version = data, version
+}
+
+// reconfigurableSource источник метрик, запоминающий применённые конфигурации.
+type reconfigurableSource struct {
+	fakeSource
+
+	mu      sync.Mutex
+	applied []config.Config
+}
+
+func (s *reconfigurableSource) Apply(cfg config.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.applied = append(s.applied, cfg)
+
+	return nil
+}
+
+func (s *reconfigurableSource) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.applied)
+}
+
+func (s *reconfigurableSource) last() config.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.applied[len(s.applied)-1]
+}
+
+func newConfigClient(t *testing.T, cfg config.Config, source Source, servers ...*configServer) *Client {
+	t.Helper()
+
+	cfg.CryptoKey = newPublicKey(t)
+	c := &Client{
+		cfg:     cfg,
+		service: source,
+		http:    resty.New(),
+		log:     newLogger(),
+		retry:   newRetryPolicy(cfg.Retry),
+
+		reportInterval: newInterval(cfg.ReportInterval),
+	}
+	for _, v := range servers {
+		addr := strings.TrimPrefix(v.URL, "http://")
+		tr, err := newHTTPTransport(cfg, addr, c.http, "")
+		require.NoError(t, err)
+		c.servers = append(c.servers, newServer(config.Server{Addr: addr}, tr))
+	}
+
+	return c
+}
+
+func TestHTTPTransport_FetchConfig(t *testing.T) {
+	ts := newConfigServer(`{"poll_interval":"5s"}`, "v1")
+	defer ts.Close()
+
+	c := newConfigClient(t, config.Config{}, fakeSource{}, ts)
+	tr := c.servers[0].transport.(*httpTransport)
+
+	data, version, err := tr.FetchConfig(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", version)
+	assert.JSONEq(t, `{"poll_interval":"5s"}`, string(data))
+
+	_, version, err = tr.FetchConfig(context.Background(), "v1")
+	assert.ErrorIs(t, err, ErrConfigNotModified)
+	assert.Equal(t, "v1", version)
+
+	ts.set("", "")
+	_, _, err = tr.FetchConfig(context.Background(), "v1")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.Code)
+}
+
+func TestClient_syncConfig(t *testing.T) {
+	down := newConfigServer("", "")
+	defer down.Close()
+	ts := newConfigServer(`{"poll_interval":"5s","collectors":{"cpu":{"enabled":false}}}`, "v1")
+	defer ts.Close()
+
+	source := &reconfigurableSource{}
+	cfg := config.Config{PollInterval: 2 * time.Second, ReportInterval: 10 * time.Second}
+	c := newConfigClient(t, cfg, source, down, ts)
+
+	require.NoError(t, c.syncConfig(context.Background()))
+	assert.Equal(t, "v1", c.configVersion)
+	require.Equal(t, 1, source.count())
+	assert.False(t, source.last().Collector("cpu").Enabled)
+	assert.Equal(t, 5*time.Second, source.last().PollInterval)
+	assert.Equal(t, 10*time.Second, c.reportInterval.get())
+
+	// та же версия не применяется повторно.
+	assert.ErrorIs(t, c.syncConfig(context.Background()), ErrConfigNotModified)
+	assert.Equal(t, 1, source.count())
+
+	// удалённая конфигурация применяется поверх локальной, а не поверх предыдущей удалённой.
+	ts.set(`{"report_interval":"1m"}`, "v2")
+	require.NoError(t, c.syncConfig(context.Background()))
+	require.Equal(t, 2, source.count())
+	assert.True(t, source.last().Collector("cpu").Enabled)
+	assert.Equal(t, 2*time.Second, source.last().PollInterval)
+	assert.Equal(t, time.Minute, c.reportInterval.get())
+
+	// некорректная конфигурация не меняет применённую версию.
+	ts.set(`{"poll_interval":"-1s"}`, "v3")
+	assert.ErrorIs(t, c.syncConfig(context.Background()), config.ErrInvalidInterval)
+	assert.Equal(t, "v2", c.configVersion)
+	assert.Equal(t, 2, source.count())
+}
+
+func TestClient_configLoop(t *testing.T) {
+	ts := newConfigServer(`{"report_interval":"10ms"}`, "v1")
+	defer ts.Close()
+
+	tr := &recordTransport{}
+	cfg := config.Config{
+		PollInterval:         time.Millisecond,
+		ReportInterval:       time.Hour,
+		RemoteConfigInterval: 10 * time.Millisecond,
+		RateLimit:            1,
+	}
+	c := newConfigClient(t, cfg, &reconfigurableSource{}, ts)
+	c.servers = append([]*server{newServer(config.Server{Addr: "test"}, tr)}, c.servers...)
+
+	c.Start(context.Background())
+
+	// интервал отправки из удалённой конфигурации применяется без перезапуска.
+	assert.Eventually(t, func() bool { return len(tr.pollCounts()) > 2 }, time.Second, 5*time.Millisecond)
+	assert.Greater(t, ts.hits.Load(), int64(0))
+
+	require.NoError(t, c.Stop(context.Background()))
+}
+
+// blockingFetcher транспорт, запрос конфигурации которого ждёт отмены контекста.
+type blockingFetcher struct {
+	recordTransport
+	canceled atomic.Bool
+}
+
+func (b *blockingFetcher) FetchConfig(ctx context.Context, _ string) ([]byte, string, error) {
+	<-ctx.Done()
+	b.canceled.Store(true)
+
+	return nil, "", ctx.Err()
+}
+
+func TestClient_StopCancelsConfigFetch(t *testing.T) {
+	tr := &blockingFetcher{}
+	cfg := config.Config{
+		PollInterval:         time.Hour,
+		ReportInterval:       time.Hour,
+		RemoteConfigInterval: time.Hour,
+	}
+	c := newRecordClient(&tr.recordTransport, cfg)
+	c.servers = []*server{newServer(config.Server{Addr: "test"}, tr)}
+
+	c.Start(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	require.NoError(t, c.Stop(ctx))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, tr.canceled.Load())
+	assert.Len(t, tr.pollCounts(), 1, "final snapshot is sent on stop")
+}
@@ -0,0 +1,182 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	random "math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusError ответ сервера с неуспешным HTTP статусом.
+type StatusError struct {
+	Code int
+	// задержка из заголовка Retry-After, если сервер её указал.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("expected status %d, got: %d", http.StatusOK, e.Code)
+}
+
+// retryPolicy повторяет отправку с экспоненциальной задержкой и случайным отклонением.
+type retryPolicy struct {
+	cfg   config.Retry
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryPolicy(cfg config.Retry) retryPolicy {
+	return retryPolicy{cfg: cfg, sleep: sleepContext}
+}
+
+// do вызывает fn, пока она не завершится успешно, с неповторяемой ошибкой или пока не кончатся попытки.
+func (p retryPolicy) do(ctx context.Context, fn func() error) error {
+	attempts := p.cfg.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		retry, after := retryable(err)
+		if !retry || attempt == attempts-1 {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		if after > delay {
+			delay = after
+		}
+
+		if sleepErr := p.sleep(ctx, delay); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+	}
+
+	return err
+}
+
+// backoff задержка перед повтором после попытки attempt, считая с нуля.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.cfg.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.cfg.InitialBackoff) * math.Pow(multiplier, float64(attempt))
+	if p.cfg.MaxBackoff > 0 && delay > float64(p.cfg.MaxBackoff) {
+		delay = float64(p.cfg.MaxBackoff)
+	}
+
+	if p.cfg.Jitter > 0 {
+		delay += delay * p.cfg.Jitter * (2*random.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// retryable сообщает, имеет ли смысл повторять отправку, и минимальную задержку от сервера.
+func retryable(err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.Code == http.StatusRequestTimeout,
+			statusErr.Code == http.StatusTooManyRequests,
+			statusErr.Code >= http.StatusInternalServerError && statusErr.Code != http.StatusNotImplemented:
+			return true, statusErr.RetryAfter
+		default:
+			return false, 0
+		}
+	}
+
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+			return true, 0
+		default:
+			return false, 0
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+
+	return false, 0
+}
+
+// failureReason возвращает причину ошибки отправки для телеметрии.
+func failureReason(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return fmt.Sprintf("status_%dxx", statusErr.Code/100)
+	}
+
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		return "grpc_" + strings.ToLower(st.Code().String())
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+
+	return "other"
+}
+
+// parseRetryAfter разбирает заголовок Retry-After в секундах или в формате HTTP даты.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
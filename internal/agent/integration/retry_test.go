@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		want      bool
+		wantAfter time.Duration
+	}{
+		{name: "service unavailable", err: &StatusError{Code: http.StatusServiceUnavailable}, want: true},
+		{
+			name:      "too many requests with retry after",
+			err:       &StatusError{Code: http.StatusTooManyRequests, RetryAfter: 3 * time.Second},
+			want:      true,
+			wantAfter: 3 * time.Second,
+		},
+		{name: "request timeout", err: &StatusError{Code: http.StatusRequestTimeout}, want: true},
+		{name: "bad request", err: &StatusError{Code: http.StatusBadRequest}, want: false},
+		{name: "forbidden", err: &StatusError{Code: http.StatusForbidden}, want: false},
+		{name: "not implemented", err: &StatusError{Code: http.StatusNotImplemented}, want: false},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, ""), want: true},
+		{name: "grpc resource exhausted", err: status.Error(codes.ResourceExhausted, ""), want: true},
+		{name: "grpc permission denied", err: status.Error(codes.PermissionDenied, ""), want: false},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "context canceled", err: context.Canceled, want: false},
+		{name: "unknown error", err: errors.New("marshal error"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, after := retryable(tt.err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantAfter, after)
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := newRetryPolicy(config.Retry{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	})
+
+	assert.Equal(t, time.Second, p.backoff(0))
+	assert.Equal(t, 2*time.Second, p.backoff(1))
+	assert.Equal(t, 4*time.Second, p.backoff(2))
+	assert.Equal(t, 5*time.Second, p.backoff(3))
+
+	p.cfg.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.LessOrEqual(t, d, 3*time.Second)
+	}
+}
+
+func TestRetryPolicy_do(t *testing.T) {
+	var delays []time.Duration
+	p := newRetryPolicy(config.Retry{
+		Attempts:       3,
+		InitialBackoff: time.Second,
+		Multiplier:     2,
+	})
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	t.Run("retry until success", func(t *testing.T) {
+		delays = nil
+		var calls int
+		err := p.do(context.Background(), func() error {
+			calls++
+			if calls < 3 {
+				return &StatusError{Code: http.StatusBadGateway}
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		delays = nil
+		var calls int
+		err := p.do(context.Background(), func() error {
+			calls++
+			return &StatusError{Code: http.StatusBadGateway}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		delays = nil
+		var calls int
+		err := p.do(context.Background(), func() error {
+			calls++
+			return &StatusError{Code: http.StatusBadRequest}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, delays)
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		delays = nil
+		var calls int
+		err := p.do(context.Background(), func() error {
+			calls++
+			if calls == 1 {
+				return &StatusError{Code: http.StatusTooManyRequests, RetryAfter: 10 * time.Second}
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{10 * time.Second}, delays)
+	})
+}
+
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "server error", err: &StatusError{Code: http.StatusBadGateway}, want: "status_5xx"},
+		{name: "client error", err: fmt.Errorf("send: %w", &StatusError{Code: http.StatusBadRequest}), want: "status_4xx"},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, ""), want: "grpc_unavailable"},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: "network"},
+		{name: "deadline", err: context.DeadlineExceeded, want: "timeout"},
+		{name: "context canceled", err: context.Canceled, want: "canceled"},
+		{name: "unknown error", err: errors.New("marshal error"), want: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, failureReason(tt.err))
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestClient_sendToRetry(t *testing.T) {
+	var calls atomic.Int64
+	ts := newTestServer(0)
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, false, ts)
+	var delays []time.Duration
+	c.retry = newRetryPolicy(config.Retry{Attempts: 3, InitialBackoff: time.Millisecond})
+	c.retry.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	err := c.sendTo(context.Background(), c.servers[0], toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)}))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), calls.Load())
+	assert.Equal(t, []time.Duration{2 * time.Second}, delays)
+}
@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+)
+
+var (
+	ErrNoServers = errors.New("no metric servers configured")
+)
+
+// server сервер метрик, на который агент отправляет данные.
+type server struct {
+	addr      string
+	transport Transport
+	// доступен ли сервер по результатам последней проверки или отправки.
+	healthy atomic.Bool
+}
+
+func newServer(cfg config.Server, transport Transport) *server {
+	srv := &server{
+		addr:      cfg.Addr,
+		transport: transport,
+	}
+	srv.healthy.Store(true)
+
+	return srv
+}
+
+// send отправляет метрики на первый доступный сервер или, в режиме FanOut, на все серверы.
+func (c *Client) send(ctx context.Context, metrics []MetricsRequest) error {
+	if len(c.servers) == 0 {
+		return ErrNoServers
+	}
+
+	if c.cfg.FanOut {
+		return c.sendFanOut(ctx, metrics)
+	}
+
+	return c.sendFailover(ctx, metrics)
+}
+
+func (c *Client) sendFailover(ctx context.Context, metrics []MetricsRequest) error {
+	l := c.log.With().Str("integration", "sendFailover").Logger()
+
+	var errs []error
+	for _, srv := range c.ordered() {
+		err := c.sendTo(ctx, srv, metrics)
+		if err == nil {
+			srv.healthy.Store(true)
+			return nil
+		}
+
+		l.Warn().Err(err).Msgf("server %s unavailable, failover", srv.addr)
+		srv.healthy.Store(false)
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ordered возвращает сначала доступные серверы в порядке приоритета,
+// затем недоступные на случай устаревшей проверки.
+func (c *Client) ordered() []*server {
+	ordered := make([]*server, 0, len(c.servers))
+	for _, v := range c.servers {
+		if v.healthy.Load() {
+			ordered = append(ordered, v)
+		}
+	}
+	for _, v := range c.servers {
+		if !v.healthy.Load() {
+			ordered = append(ordered, v)
+		}
+	}
+
+	return ordered
+}
+
+func (c *Client) sendFanOut(ctx context.Context, metrics []MetricsRequest) error {
+	l := c.log.With().Str("integration", "sendFanOut").Logger()
+
+	errs := make([]error, len(c.servers))
+	wg := &sync.WaitGroup{}
+	for i, srv := range c.servers {
+		wg.Add(1)
+		go func(i int, srv *server) {
+			defer wg.Done()
+			errs[i] = c.sendTo(ctx, srv, metrics)
+			srv.healthy.Store(errs[i] == nil)
+		}(i, srv)
+	}
+	wg.Wait()
+
+	var failed int
+	for i, err := range errs {
+		if err != nil {
+			l.Warn().Err(err).Msgf("server %s unavailable", c.servers[i].addr)
+			failed++
+		}
+	}
+	if failed == len(c.servers) {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// sendTo отправляет метрики на сервер с повторами по политике retry.
+func (c *Client) sendTo(ctx context.Context, srv *server, metrics []MetricsRequest) error {
+	start := time.Now()
+	err := c.retry.do(ctx, func() error {
+		return srv.transport.Send(ctx, metrics)
+	})
+	if err != nil {
+		c.telemetry.SendFailed(failureReason(err))
+		return fmt.Errorf("srv.transport.Send: %w", err)
+	}
+	c.telemetry.BatchSent(time.Since(start))
+
+	return nil
+}
+
+// healthCheckPeriodic периодически проверяет доступность серверов через /ping.
+func (c *Client) healthCheckPeriodic(ctx context.Context) {
+	if len(c.servers) < 2 || c.cfg.HealthCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, srv := range c.servers {
+				srv.healthy.Store(c.healthCheck(ctx, srv) == nil)
+			}
+		}
+	}
+}
+
+func (c *Client) healthCheck(ctx context.Context, srv *server) error {
+	request := c.http.R().SetContext(ctx)
+	if c.cfg.Token != "" {
+		request.SetAuthToken(c.cfg.Token)
+	}
+
+	resp, err := request.Get(fmt.Sprintf("http://%s/ping", srv.addr))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("expected status %d, got: %d", http.StatusOK, resp.StatusCode())
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogger() zerolog.Logger {
+	out := zerolog.ConsoleWriter{
+		Out:        os.Stderr,
+		TimeFormat: "2006-01-02 15:04:05 -0700",
+		NoColor:    true,
+	}
+
+	l := zerolog.New(out)
+
+	return l.Level(zerolog.InfoLevel).With().Timestamp().Logger()
+}
+
+func newPublicKey(t testing.TB) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "pbkey.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	return path
+}
+
+type testServer struct {
+	*httptest.Server
+	hits atomic.Int64
+}
+
+func newTestServer(status int) *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.hits.Add(1)
+		w.WriteHeader(status)
+	}))
+
+	return ts
+}
+
+func (ts *testServer) addr() string {
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func newTestClient(t *testing.T, fanOut bool, servers ...*testServer) *Client {
+	t.Helper()
+
+	cfg := config.Config{
+		CryptoKey: newPublicKey(t),
+		FanOut:    fanOut,
+	}
+	for _, v := range servers {
+		cfg.Servers = append(cfg.Servers, config.Server{Addr: v.addr()})
+	}
+
+	l := newLogger()
+	c := &Client{
+		cfg:  cfg,
+		http: resty.New(),
+		log:  l,
+	}
+	for _, v := range cfg.Servers {
+		tr, err := newHTTPTransport(cfg, v.Addr, c.http, "")
+		require.NoError(t, err)
+		c.servers = append(c.servers, newServer(v, tr))
+	}
+
+	return c
+}
+
+func TestClient_sendFailover(t *testing.T) {
+	down := newTestServer(http.StatusInternalServerError)
+	defer down.Close()
+	up := newTestServer(http.StatusOK)
+	defer up.Close()
+
+	c := newTestClient(t, false, down, up)
+
+	err := c.send(context.Background(), toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)}))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), down.hits.Load())
+	assert.Equal(t, int64(1), up.hits.Load())
+	assert.False(t, c.servers[0].healthy.Load())
+	assert.True(t, c.servers[1].healthy.Load())
+
+	// недоступный сервер пропускается, пока проверка не вернёт его в строй.
+	err = c.send(context.Background(), toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)}))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), down.hits.Load())
+	assert.Equal(t, int64(2), up.hits.Load())
+}
+
+func TestClient_sendFailoverAllDown(t *testing.T) {
+	down := newTestServer(http.StatusInternalServerError)
+	defer down.Close()
+
+	c := newTestClient(t, false, down)
+
+	err := c.send(context.Background(), toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)}))
+	assert.Error(t, err)
+}
+
+func TestClient_sendFanOut(t *testing.T) {
+	first := newTestServer(http.StatusOK)
+	defer first.Close()
+	second := newTestServer(http.StatusOK)
+	defer second.Close()
+	down := newTestServer(http.StatusInternalServerError)
+	defer down.Close()
+
+	c := newTestClient(t, true, first, second, down)
+
+	err := c.send(context.Background(), toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)}))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), first.hits.Load())
+	assert.Equal(t, int64(1), second.hits.Load())
+	assert.Equal(t, int64(1), down.hits.Load())
+	assert.False(t, c.servers[2].healthy.Load())
+}
+
+func TestClient_healthCheck(t *testing.T) {
+	up := newTestServer(http.StatusOK)
+	defer up.Close()
+	down := newTestServer(http.StatusServiceUnavailable)
+	defer down.Close()
+
+	c := newTestClient(t, false, up, down)
+
+	assert.NoError(t, c.healthCheck(context.Background(), c.servers[0]))
+	assert.Error(t, c.healthCheck(context.Background(), c.servers[1]))
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+)
+
+// snapshot метрики одного интервала отправки вместе с числом опросов за этот интервал.
+// Снимок неизменяем после создания, поэтому его можно передавать между горутинами.
+type snapshot struct {
+	metrics   []metric.Metric
+	pollCount int64
+	timestamp time.Time
+}
+
+// requests переводит снимок в запросы к серверу, добавляя счётчик опросов PollCount.
+func (s snapshot) requests() []MetricsRequest {
+	pollCount := metric.NewCounter("PollCount", s.pollCount)
+	pollCount.Timestamp = s.timestamp
+
+	return append(toRequests(s.metrics), toRequest(pollCount))
+}
+
+// batch часть снимка, отправляемая одним запросом, с числом опросов и временем снимка.
+type batch struct {
+	requests  []MetricsRequest
+	pollCount int64
+	timestamp time.Time
+}
+
+// batches делит снимок на батчи по ограничениям из конфигурации.
+// Счётчик опросов PollCount попадает ровно в один батч.
+func (c *Client) batches(s snapshot) []batch {
+	parts := splitBatches(c.tag(s.requests()), c.cfg.Batch.MaxCount, c.cfg.Batch.MaxBytes)
+
+	res := make([]batch, 0, len(parts))
+	for _, v := range parts {
+		res = append(res, batch{requests: v, pollCount: s.pollCount, timestamp: s.timestamp})
+	}
+
+	return res
+}
+
+// PollCounter источник метрик, который считает свои опросы для счётчика PollCount.
+type PollCounter interface {
+	// Polls возвращает число опросов с прошлого вызова и обнуляет его.
+	Polls() int64
+}
+
+// polls возвращает число опросов источника для снимка, 0 если источник их не считает.
+func (c *Client) polls() int64 {
+	if p, ok := c.service.(PollCounter); ok {
+		return p.Polls()
+	}
+
+	return 0
+}
+
+// takeSnapshot снимает метрики для очередной отправки.
+// В режиме отправки при изменении в снимок попадают только изменившиеся метрики.
+func (c *Client) takeSnapshot() snapshot {
+	now := time.Now()
+	metrics := c.service.GetMetric()
+	if c.change != nil {
+		n := len(metrics)
+		metrics = c.change.filter(metrics, now)
+		c.telemetry.Suppressed(n - len(metrics))
+	}
+
+	return snapshot{
+		metrics:   metrics,
+		pollCount: c.polls(),
+		timestamp: now,
+	}
+}
@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct{}
+
+func (fakeSource) Start(_ context.Context) {}
+
+func (fakeSource) GetMetric() []metric.Metric {
+	return []metric.Metric{metric.NewGauge("Alloc", 1)}
+}
+
+// pollSource источник, который опрашивается один раз за каждый снимок.
+type pollSource struct {
+	fakeSource
+	polls atomic.Int64
+}
+
+func (p *pollSource) GetMetric() []metric.Metric {
+	p.polls.Add(1)
+	return p.fakeSource.GetMetric()
+}
+
+func (p *pollSource) Polls() int64 {
+	return p.polls.Swap(0)
+}
+
+// recordTransport запоминает отправленные батчи.
+// Если block равен true, отправка ждёт отмены контекста.
+type recordTransport struct {
+	block  bool
+	closed atomic.Bool
+
+	mu      sync.Mutex
+	batches [][]MetricsRequest
+}
+
+func (r *recordTransport) Send(ctx context.Context, metrics []MetricsRequest) error {
+	if r.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, metrics)
+
+	return nil
+}
+
+func (r *recordTransport) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+func (r *recordTransport) pollCounts() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []int64
+	for _, batch := range r.batches {
+		for _, v := range batch {
+			if v.ID == "PollCount" {
+				res = append(res, *v.Delta)
+			}
+		}
+	}
+
+	return res
+}
+
+func newRecordClient(tr *recordTransport, cfg config.Config) *Client {
+	return &Client{
+		cfg:     cfg,
+		service: fakeSource{},
+		log:     newLogger(),
+		servers: []*server{newServer(config.Server{Addr: "test"}, tr)},
+		retry:   newRetryPolicy(cfg.Retry),
+
+		reportInterval: newInterval(cfg.ReportInterval),
+	}
+}
+
+func TestClient_StartStop(t *testing.T) {
+	tr := &recordTransport{}
+	cfg := config.Config{
+		PollInterval:   time.Millisecond,
+		ReportInterval: 20 * time.Millisecond,
+		RateLimit:      4,
+	}
+	c := newRecordClient(tr, cfg)
+	source := &pollSource{}
+	c.service = source
+
+	c.Start(context.Background())
+
+	require.Eventually(t, func() bool { return len(tr.pollCounts()) >= 3 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, c.Stop(context.Background()))
+
+	tr.mu.Lock()
+	for _, batch := range tr.batches {
+		var n int
+		for _, v := range batch {
+			if v.ID == "PollCount" {
+				n++
+			}
+		}
+		assert.Equal(t, 1, n, "each snapshot carries exactly one PollCount")
+	}
+	tr.mu.Unlock()
+
+	// каждый опрос источника попадает ровно в один снимок.
+	var sum int64
+	for _, v := range tr.pollCounts() {
+		sum += v
+	}
+	assert.Equal(t, int64(len(tr.pollCounts())), sum)
+	assert.Zero(t, source.polls.Load())
+	assert.True(t, tr.closed.Load())
+
+	// повторная остановка ничего не делает.
+	require.NoError(t, c.Stop(context.Background()))
+}
+
+// parallelTransport считает одновременные отправки.
+type parallelTransport struct {
+	recordTransport
+	inflight atomic.Int64
+	max      atomic.Int64
+}
+
+func (p *parallelTransport) Send(ctx context.Context, metrics []MetricsRequest) error {
+	n := p.inflight.Add(1)
+	defer p.inflight.Add(-1)
+	for {
+		m := p.max.Load()
+		if n <= m || p.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	return p.recordTransport.Send(ctx, metrics)
+}
+
+func TestClient_ParallelBatches(t *testing.T) {
+	tr := &parallelTransport{}
+	cfg := config.Config{
+		PollInterval:   time.Hour,
+		ReportInterval: time.Hour,
+		RateLimit:      4,
+		Batch:          config.Batch{MaxCount: 1},
+	}
+	c := newRecordClient(&tr.recordTransport, cfg)
+	c.servers = []*server{newServer(config.Server{Addr: "test"}, tr)}
+
+	c.Start(context.Background())
+	require.NoError(t, c.Stop(context.Background()))
+
+	// Alloc и PollCount одного снимка отправляются разными воркерами одновременно.
+	tr.mu.Lock()
+	assert.Len(t, tr.batches, 2)
+	tr.mu.Unlock()
+	assert.Equal(t, int64(2), tr.max.Load())
+	assert.Equal(t, []int64{0}, tr.pollCounts())
+}
+
+func TestClient_StopFinalReport(t *testing.T) {
+	tr := &recordTransport{}
+	c := newRecordClient(tr, config.Config{
+		PollInterval:   time.Hour,
+		ReportInterval: time.Hour,
+	})
+
+	c.Start(context.Background())
+	require.NoError(t, c.Stop(context.Background()))
+
+	require.Len(t, tr.pollCounts(), 1, "final snapshot is sent on stop")
+	assert.True(t, tr.closed.Load())
+}
+
+func TestClient_StopDeadline(t *testing.T) {
+	tr := &recordTransport{block: true}
+	c := newRecordClient(tr, config.Config{
+		PollInterval:   time.Hour,
+		ReportInterval: time.Hour,
+	})
+
+	c.Start(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Stop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, tr.closed.Load())
+}
+
+func TestClient_ReportTags(t *testing.T) {
+	tr := &recordTransport{}
+	cfg := config.Default()
+	cfg.Hostname = "web-1"
+	cfg.InstanceID = "a1"
+	cfg.Tags = map[string]string{"env": "prod"}
+
+	c := newRecordClient(tr, cfg)
+	c.tags = cfg.Identity()
+
+	require.NoError(t, c.Report(context.Background(), []metric.Metric{
+		metric.NewGauge("Alloc", 1),
+		metric.NewCounter("Requests", 2),
+	}))
+
+	require.Len(t, tr.batches, 1)
+	for _, v := range tr.batches[0] {
+		assert.Equal(t, map[string]string{"host": "web-1", "instance": "a1", "env": "prod"}, v.Tags, v.ID)
+	}
+}
+
+func TestClient_StopWithoutStart(t *testing.T) {
+	tr := &recordTransport{}
+	c := newRecordClient(tr, config.Config{PollInterval: time.Hour, ReportInterval: time.Hour})
+
+	require.NoError(t, c.Stop(context.Background()))
+	assert.True(t, tr.closed.Load())
+	assert.Empty(t, tr.pollCounts())
+}
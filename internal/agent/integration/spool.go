@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// deliver отправляет батч на сервер, а при временной недоступности серверов сохраняет его в очередь на диске.
+// Батч, отклонённый сервером, например из-за подписи или авторизации, отбрасывается.
+// Пока очередь не пуста, новые батчи ставятся в её конец, чтобы сохранить порядок отправки.
+func (c *Client) deliver(ctx context.Context, metrics []MetricsRequest) error {
+	l := c.log.With().Str("integration", "deliver").Logger()
+
+	if c.spool == nil {
+		err := c.send(ctx, metrics)
+		if err != nil {
+			c.telemetry.Dropped(len(metrics))
+		}
+		return err
+	}
+
+	if c.spool.Len() == 0 {
+		err := c.send(ctx, metrics)
+		if err == nil {
+			return nil
+		}
+		if !transient(err) {
+			// батч, который сервер не примет, нельзя ставить в очередь: он заблокирует её начало.
+			c.telemetry.Dropped(len(metrics))
+			return err
+		}
+		l.Warn().Err(err).Msg("c.send, spool batch")
+	}
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err = c.spool.Push(data); err != nil {
+		c.telemetry.Dropped(len(metrics))
+		return fmt.Errorf("c.spool.Push: %w", err)
+	}
+
+	return nil
+}
+
+// startReplay запускает воспроизведение очереди в фоне, если оно ещё не выполняется.
+// Пока серверы недоступны, один проход с повторами может длиться дольше интервала отправки,
+// поэтому новые проходы не запускаются поверх незавершённого.
+func (c *Client) startReplay(ctx context.Context) {
+	if c.spool == nil || c.spool.Len() == 0 || !c.replaying.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.loops.Add(1)
+	go func() {
+		defer c.loops.Done()
+		defer c.replaying.Store(false)
+		c.replaySpool(ctx)
+	}()
+}
+
+// replaySpool отправляет накопленные в очереди батчи в порядке их добавления.
+func (c *Client) replaySpool(ctx context.Context) {
+	l := c.log.With().Str("integration", "replaySpool").Logger()
+
+	if c.spool == nil || c.spool.Len() == 0 {
+		return
+	}
+
+	replayed, err := c.spool.Replay(func(data []byte) error {
+		var metrics []MetricsRequest
+		if err := json.Unmarshal(data, &metrics); err != nil {
+			// нечитаемый батч отправить невозможно, он удаляется из очереди.
+			l.Err(err).Msg("json.Unmarshal, drop batch")
+			return nil
+		}
+
+		err := c.send(ctx, metrics)
+		if err != nil && !transient(err) {
+			l.Err(err).Msg("c.send, drop batch")
+			c.telemetry.Dropped(len(metrics))
+			return nil
+		}
+
+		return err
+	})
+	if err != nil {
+		l.Warn().Err(err).Msg("c.spool.Replay")
+	}
+
+	l.Info().Msgf("spool replayed: %d, batches: %d, size: %d, dropped: %d",
+		replayed, c.spool.Len(), c.spool.Size(), c.spool.Dropped())
+}
+
+// transient сообщает, можно ли отправить батч позже: ошибка временная хотя бы для одного сервера
+// или отправка прервана остановкой агента.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, v := range joined.Unwrap() {
+			if transient(v) {
+				return true
+			}
+		}
+		return false
+	}
+
+	retry, _ := retryable(err)
+
+	return retry
+}
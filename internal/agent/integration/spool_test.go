@@ -0,0 +1,163 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/1Asi1/metric-track.git/internal/agent/spool"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_deliverSpool(t *testing.T) {
+	var status atomic.Int64
+	status.Store(http.StatusServiceUnavailable)
+	ts := newTestServer(0)
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, false, ts)
+	sp, err := spool.New(t.TempDir(), 0)
+	require.NoError(t, err)
+	c.spool = sp
+
+	ctx := context.Background()
+	require.NoError(t, c.deliver(ctx, toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)})))
+	assert.Equal(t, 1, sp.Len())
+
+	// пока очередь не пуста, новые батчи ставятся в её конец без попытки отправки.
+	require.NoError(t, c.deliver(ctx, toRequests([]metric.Metric{metric.NewGauge("Alloc", 2.0)})))
+	assert.Equal(t, 2, sp.Len())
+	assert.Equal(t, int64(1), ts.hits.Load())
+
+	c.replaySpool(ctx)
+	assert.Equal(t, 2, sp.Len())
+
+	status.Store(http.StatusOK)
+	c.replaySpool(ctx)
+	assert.Equal(t, 0, sp.Len())
+	assert.Equal(t, int64(4), ts.hits.Load())
+}
+
+func TestClient_deliverSpoolRejected(t *testing.T) {
+	var status atomic.Int64
+	status.Store(http.StatusServiceUnavailable)
+	ts := newTestServer(0)
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, false, ts)
+	c.telemetry = telemetry.New()
+	sp, err := spool.New(t.TempDir(), 0)
+	require.NoError(t, err)
+	c.spool = sp
+
+	ctx := context.Background()
+
+	// отклонённый сервером батч не попадает в очередь.
+	status.Store(http.StatusBadRequest)
+	assert.Error(t, c.deliver(ctx, toRequests([]metric.Metric{metric.NewGauge("Alloc", 1.0)})))
+	assert.Equal(t, 0, sp.Len())
+
+	status.Store(http.StatusServiceUnavailable)
+	require.NoError(t, c.deliver(ctx, toRequests([]metric.Metric{metric.NewGauge("Alloc", 2.0)})))
+	require.NoError(t, c.deliver(ctx, toRequests([]metric.Metric{metric.NewGauge("Alloc", 3.0)})))
+	assert.Equal(t, 2, sp.Len())
+
+	// батч, который сервер больше не принимает, удаляется и не блокирует очередь.
+	status.Store(http.StatusUnauthorized)
+	c.replaySpool(ctx)
+	assert.Equal(t, 0, sp.Len())
+
+	got := make(map[string]float64)
+	metrics, err := c.telemetry.Collect(ctx)
+	require.NoError(t, err)
+	for _, v := range metrics {
+		got[v.Name] = v.Value + float64(v.Delta)
+	}
+	assert.Equal(t, 3.0, got["AgentDroppedMetrics"])
+}
+
+func Test_transient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "unavailable", err: &StatusError{Code: http.StatusServiceUnavailable}, want: true},
+		{name: "bad request", err: &StatusError{Code: http.StatusBadRequest}, want: false},
+		{name: "forbidden", err: fmt.Errorf("send: %w", &StatusError{Code: http.StatusForbidden}), want: false},
+		{name: "canceled", err: context.Canceled, want: true},
+		{
+			name: "one server transient",
+			err:  errors.Join(&StatusError{Code: http.StatusUnauthorized}, &StatusError{Code: http.StatusBadGateway}),
+			want: true,
+		},
+		{
+			name: "all servers rejected",
+			err:  errors.Join(&StatusError{Code: http.StatusUnauthorized}, &StatusError{Code: http.StatusBadRequest}),
+			want: false,
+		},
+		{name: "no servers", err: ErrNoServers, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, transient(tt.err))
+		})
+	}
+}
+
+// stallTransport ждёт сигнала release и отвечает временной ошибкой.
+type stallTransport struct {
+	recordTransport
+	calls   atomic.Int64
+	release chan struct{}
+}
+
+func (s *stallTransport) Send(ctx context.Context, _ []MetricsRequest) error {
+	s.calls.Add(1)
+	<-s.release
+
+	return context.DeadlineExceeded
+}
+
+func TestClient_startReplaySingle(t *testing.T) {
+	tr := &stallTransport{release: make(chan struct{})}
+	c := newRecordClient(&tr.recordTransport, config.Config{})
+	c.servers = []*server{newServer(config.Server{Addr: "test"}, tr)}
+
+	sp, err := spool.New(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, sp.Push([]byte(`[{"id":"Alloc","type":"gauge","value":1}]`)))
+	c.spool = sp
+
+	ctx := context.Background()
+	c.startReplay(ctx)
+	require.Eventually(t, func() bool { return tr.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	// тики во время незавершённого воспроизведения не запускают новых проходов.
+	for i := 0; i < 5; i++ {
+		c.startReplay(ctx)
+	}
+
+	close(tr.release)
+	c.loops.Wait()
+
+	assert.Equal(t, int64(1), tr.calls.Load())
+	assert.Equal(t, 1, sp.Len())
+	assert.False(t, c.replaying.Load())
+}
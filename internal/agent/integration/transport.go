@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/go-resty/resty/v2"
+)
+
+// Протоколы отправки метрик.
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+var (
+	ErrUnknownProtocol    = errors.New("unknown protocol")
+	ErrConfigNotModified  = errors.New("agent config not modified")
+	ErrConfigNotSupported = errors.New("agent config is not supported by transport")
+)
+
+// Transport способ доставки батча метрик на один сервер.
+type Transport interface {
+	// Send отправляет батч метрик.
+	Send(ctx context.Context, metrics []MetricsRequest) error
+	// Close освобождает соединения с сервером.
+	Close() error
+}
+
+// ConfigFetcher транспорт, умеющий получать удалённую конфигурацию агента.
+type ConfigFetcher interface {
+	// FetchConfig возвращает конфигурацию и её версию.
+	// Если версия на сервере совпадает с version, возвращается ErrConfigNotModified.
+	FetchConfig(ctx context.Context, version string) ([]byte, string, error)
+}
+
+// newTransport создаёт транспорт для сервера по протоколу из конфигурации.
+// Адрес агента для заголовка X-Real-IP определяется по маршруту до этого сервера.
+func newTransport(cfg config.Config, srv config.Server, client *resty.Client) (Transport, error) {
+	switch cfg.Protocol {
+	case ProtocolHTTP, "":
+		return newHTTPTransport(cfg, srv.Addr, client, outboundIP(srv.Addr))
+	case ProtocolGRPC:
+		if srv.GrpcAddr == "" {
+			return nil, fmt.Errorf("grpc address for server %s is empty", srv.Addr)
+		}
+		return newGRPCTransport(cfg, srv.GrpcAddr, outboundIP(srv.GrpcAddr))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProtocol, cfg.Protocol)
+	}
+}
@@ -0,0 +1,97 @@
+package metric
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind тип метрики.
+type Kind string
+
+const (
+	// KindGauge метрика, значение которой заменяет предыдущее.
+	KindGauge Kind = "gauge"
+	// KindCounter метрика, значение которой прибавляется к накопленному на сервере.
+	KindCounter Kind = "counter"
+)
+
+// Metric значение метрики, снятое агентом.
+type Metric struct {
+	// имя метрики.
+	Name string
+	// тип метрики, определяет, какое из полей Value или Delta заполнено.
+	Kind Kind
+	// значение для KindGauge.
+	Value float64
+	// приращение для KindCounter.
+	Delta int64
+	// дополнительные метки метрики.
+	Labels map[string]string
+	// время снятия значения.
+	Timestamp time.Time
+}
+
+// NewGauge создаёт метрику типа gauge.
+func NewGauge(name string, value float64) Metric {
+	return Metric{
+		Name:      name,
+		Kind:      KindGauge,
+		Value:     value,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewCounter создаёт метрику типа counter.
+func NewCounter(name string, delta int64) Metric {
+	return Metric{
+		Name:      name,
+		Kind:      KindCounter,
+		Delta:     delta,
+		Timestamp: time.Now(),
+	}
+}
+
+// WithLabels возвращает копию метрики с добавленными метками.
+func (m Metric) WithLabels(labels map[string]string) Metric {
+	res := make(map[string]string, len(m.Labels)+len(labels))
+	for k, v := range m.Labels {
+		res[k] = v
+	}
+	for k, v := range labels {
+		res[k] = v
+	}
+	m.Labels = res
+
+	return m
+}
+
+// ID имя метрики на сервере, метки добавляются к имени в виде name{key="value",...}
+// в порядке сортировки ключей, чтобы одна и та же метрика всегда получала одно имя.
+func (m Metric) ID() string {
+	if len(m.Labels) == 0 {
+		return m.Name
+	}
+
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(m.Name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(m.Labels[k]))
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
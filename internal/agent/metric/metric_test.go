@@ -0,0 +1,44 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetric_ID(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric Metric
+		want   string
+	}{
+		{
+			name:   "without labels",
+			metric: NewGauge("Alloc", 1),
+			want:   "Alloc",
+		},
+		{
+			name:   "sorted labels",
+			metric: NewGauge("DiskUsed", 1).WithLabels(map[string]string{"mount": "/", "device": "sda1"}),
+			want:   `DiskUsed{device="sda1",mount="/"}`,
+		},
+		{
+			name:   "quoted value",
+			metric: NewCounter("Requests", 1).WithLabels(map[string]string{"path": `a"b`}),
+			want:   `Requests{path="a\"b"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.metric.ID())
+		})
+	}
+}
+
+func TestMetric_WithLabels(t *testing.T) {
+	m := NewGauge("Alloc", 1).WithLabels(map[string]string{"a": "1"})
+	n := m.WithLabels(map[string]string{"b": "2"})
+
+	assert.Equal(t, map[string]string{"a": "1"}, m.Labels)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, n.Labels)
+}
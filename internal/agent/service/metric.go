@@ -1,84 +1,186 @@
 package service
 
 import (
-	"runtime"
+	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/1Asi1/metric-track.git/internal/agent/collector"
 	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
 	"github.com/rs/zerolog"
-	"github.com/shirou/gopsutil/cpu"
-	"github.com/shirou/gopsutil/mem"
 )
 
-type Gauge float64
-type Counter int64
+type Service struct {
+	cfg      config.Config
+	log      zerolog.Logger
+	registry *collector.Registry
+	disk     *collector.Disk
+	network  *collector.Network
+	// коллекторы групп, например exec, по имени коллектора.
+	members map[string]member
+}
 
-type Metric struct {
-	Type map[string]any
+// member коллектор группы со своим интервалом опроса.
+type member struct {
+	group    string
+	interval time.Duration
 }
 
-type Service struct {
-	cfg config.Config
-	log zerolog.Logger
+// New создаёт сервис со встроенными коллекторами и коллекторами из конфигурации.
+// Если tel не nil, время опроса коллекторов учитывается в телеметрии, а сама телеметрия
+// отправляется как коллектор agent.
+func New(cfg config.Config, tel *telemetry.Telemetry, log zerolog.Logger) Service {
+	s := Service{
+		cfg:      cfg,
+		log:      log,
+		registry: collector.NewRegistry(log),
+		disk:     collector.NewDisk(collector.Filter(cfg.Disk.Mounts), collector.Filter(cfg.Disk.FSTypes)),
+		network:  collector.NewNetwork(collector.Filter(cfg.Network.Interfaces), cfg.Network.TCPStates, log),
+		members:  make(map[string]member),
+	}
+	// PollCount считает опросы runtime-метрик.
+	s.registry.CountPolls("runtime")
+
+	if tel != nil {
+		s.registry.Observe(tel)
+		if err := s.Register(tel); err != nil {
+			log.Err(err).Msg("s.Register")
+		}
+	}
+
+	for _, v := range []collector.Collector{
+		collector.NewRuntime(),
+		collector.NewMemory(),
+		collector.NewCPU(),
+		s.disk,
+		s.network,
+	} {
+		if err := s.Register(v); err != nil {
+			log.Err(err).Msg("s.Register")
+		}
+	}
+
+	if len(cfg.Processes) != 0 {
+		targets := make([]collector.ProcessTarget, 0, len(cfg.Processes))
+		for _, v := range cfg.Processes {
+			targets = append(targets, collector.ProcessTarget(v))
+		}
+
+		processes, err := collector.NewProcesses(targets)
+		if err != nil {
+			log.Err(err).Msg("collector.NewProcesses")
+		} else if err = s.Register(processes); err != nil {
+			log.Err(err).Msg("s.Register")
+		}
+	}
+
+	for _, v := range cfg.Scripts {
+		script := collector.NewExec(collector.Script{
+			Name:    v.Name,
+			Command: v.Command,
+			Args:    v.Args,
+			Timeout: v.Timeout,
+			Format:  v.Format,
+		})
+		if err := s.registerGroup("exec", script, v.Interval); err != nil {
+			log.Err(err).Msg("s.registerGroup")
+		}
+	}
+
+	for _, v := range cfg.Scrape {
+		scrape := collector.NewPrometheus(collector.ScrapeTarget{
+			Name:    v.Name,
+			URL:     v.URL,
+			Timeout: v.Timeout,
+		})
+		if err := s.registerGroup("prometheus", scrape, v.Interval); err != nil {
+			log.Err(err).Msg("s.registerGroup")
+		}
+	}
+
+	return s
 }
 
-func New(cfg config.Config, log zerolog.Logger) Service {
-	return Service{
-		cfg: cfg,
-		log: log,
+// Register добавляет коллектор с настройками из конфигурации.
+// Выключенный коллектор не опрашивается, пока его не включит удалённая конфигурация.
+func (s Service) Register(c collector.Collector) error {
+	settings := s.settings(s.cfg, c.Name())
+	if settings.Disabled {
+		s.log.Info().Msgf("collector %s is disabled", c.Name())
 	}
+
+	return s.registry.Register(c, settings)
 }
 
-func (s Service) GetMetric() Metric {
-	l := s.log.With().Str("service", "GetMetric").Logger()
+// registerGroup добавляет один из коллекторов группы group, например exec.
+// Группа включается и настраивается в конфигурации целиком, interval переопределяет интервал группы.
+func (s Service) registerGroup(group string, c collector.Collector, interval time.Duration) error {
+	s.members[c.Name()] = member{group: group, interval: interval}
 
-	memory, err := mem.VirtualMemory()
-	if err != nil {
-		l.Err(err).Msg("mem.VirtualMemory")
+	return s.registry.Register(c, s.settings(s.cfg, c.Name()))
+}
+
+// settings возвращает настройки коллектора name по конфигурации cfg.
+func (s Service) settings(cfg config.Config, name string) collector.Settings {
+	m, ok := s.members[name]
+	if !ok {
+		settings := cfg.Collector(name)
+		return collector.Settings{
+			PollInterval: settings.PollInterval,
+			Aggregate:    settings.Aggregate,
+			Disabled:     !settings.Enabled,
+		}
+	}
+
+	settings := cfg.Collector(m.group)
+	if m.interval != 0 {
+		settings.PollInterval = m.interval
 	}
-	cpuMetric, err := cpu.Counts(true)
-	if err != nil {
-		l.Err(err).Msg("cpu.Counts")
+
+	return collector.Settings{
+		PollInterval: settings.PollInterval,
+		Aggregate:    settings.Aggregate,
+		Disabled:     !settings.Enabled,
+	}
+}
+
+// Apply применяет к запущенным коллекторам настройки и фильтры из конфигурации cfg.
+// Добавить новые коллекторы, например скрипты, можно только перезапуском агента.
+func (s Service) Apply(cfg config.Config) error {
+	var errs []error
+	for _, name := range s.registry.All() {
+		if err := s.registry.Configure(name, s.settings(cfg, name)); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
-	var res = map[string]any{
-		"Alloc":           m.Alloc,
-		"BuckHashSys":     m.BuckHashSys,
-		"Frees":           m.Frees,
-		"GCCPUFraction":   m.GCCPUFraction,
-		"GCSys":           m.GCSys,
-		"HeapAlloc":       m.HeapAlloc,
-		"HeapIdle":        m.HeapIdle,
-		"HeapInuse":       m.HeapInuse,
-		"HeapObjects":     m.HeapObjects,
-		"HeapReleased":    m.HeapReleased,
-		"HeapSys":         m.HeapSys,
-		"LastGC":          m.LastGC,
-		"Lookups":         m.Lookups,
-		"MCacheInuse":     m.MCacheInuse,
-		"MCacheSys":       m.MCacheSys,
-		"MSpanInuse":      m.MSpanInuse,
-		"MSpanSys":        m.MSpanSys,
-		"Mallocs":         m.Mallocs,
-		"NextGC":          m.NextGC,
-		"NumForcedGC":     m.NumForcedGC,
-		"NumGC":           m.NumGC,
-		"OtherSys":        m.OtherSys,
-		"PauseTotalNs":    m.PauseTotalNs,
-		"StackInuse":      m.StackInuse,
-		"StackSys":        m.StackSys,
-		"Sys":             m.Sys,
-		"TotalAlloc":      m.TotalAlloc,
-		"RandomValue":     Gauge(0),
-		"PollCount":       Counter(0),
-		"TotalMemory":     memory.Total,
-		"FreeMemory":      memory.Free,
-		"CPUutilization1": cpuMetric,
+	s.disk.SetFilters(collector.Filter(cfg.Disk.Mounts), collector.Filter(cfg.Disk.FSTypes))
+	s.network.SetFilter(collector.Filter(cfg.Network.Interfaces), cfg.Network.TCPStates)
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("s.registry.Configure: %w", err)
 	}
 
-	l.Debug().Msgf("data value: %+v", res)
+	return nil
+}
+
+// Start запускает опрос коллекторов до отмены ctx.
+func (s Service) Start(ctx context.Context) {
+	s.registry.Start(ctx)
+}
+
+// Polls возвращает число опросов runtime-коллектора с прошлого вызова.
+func (s Service) Polls() int64 {
+	return s.registry.Polls()
+}
+
+func (s Service) GetMetric() []metric.Metric {
+	res := s.registry.Metrics()
+
+	s.log.Debug().Msgf("data value: %+v", res)
 
-	return Metric{Type: res}
+	return res
 }
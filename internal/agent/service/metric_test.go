@@ -1,12 +1,17 @@
 package service
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/1Asi1/metric-track.git/internal/agent/telemetry"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newLogger() zerolog.Logger {
@@ -22,56 +27,126 @@ func newLogger() zerolog.Logger {
 }
 
 func Test_service_GetMetric(t *testing.T) {
-	var cfg config.Config
+	cfg := config.Config{PollInterval: time.Second}
 	tests := []struct {
 		name string
-		want Metric
+		want map[string]metric.Kind
 	}{
 		{
 			name: "positive",
-			want: Metric{},
+			want: map[string]metric.Kind{
+				"Alloc":         metric.KindGauge,
+				"GCCPUFraction": metric.KindGauge,
+				"RandomValue":   metric.KindGauge,
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := newLogger()
-			s := Service{
-				cfg: cfg,
-				log: l,
-			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			s := New(cfg, nil, newLogger())
+			s.Start(ctx)
 
 			data := s.GetMetric()
 
-			assert.NotEqual(t, 0, data.Type["RandomValue"])
+			got := make(map[string]metric.Metric, len(data))
+			for _, v := range data {
+				got[v.Name] = v
+			}
+
+			for name, kind := range tt.want {
+				assert.Equal(t, kind, got[name].Kind, name)
+			}
+			assert.NotEqual(t, 0.0, got["RandomValue"].Value)
+			assert.NotContains(t, got, "PollCount")
+			assert.Equal(t, int64(1), s.Polls())
 		})
 	}
 }
 
 func TestNew(t *testing.T) {
-	type args struct {
-		cfg config.Config
-		log zerolog.Logger
-	}
 	tests := []struct {
 		name string
-		args args
-		want Service
+		cfg  config.Config
+		tel  *telemetry.Telemetry
+		want []string
 	}{
+		{
+			name: "telemetry",
+			cfg:  config.Config{PollInterval: time.Second},
+			tel:  telemetry.New(),
+			want: []string{"agent", "runtime", "memory", "cpu", "disk", "network"},
+		},
 		{
 			name: "positive",
-			args: args{
-				cfg: config.Config{},
-				log: newLogger(),
+			cfg:  config.Config{PollInterval: time.Second},
+			want: []string{"runtime", "memory", "cpu", "disk", "network"},
+		},
+		{
+			name: "disabled collector",
+			cfg: config.Config{
+				PollInterval: time.Second,
+				Collectors:   map[string]config.Collector{"memory": {Enabled: false}},
+			},
+			want: []string{"runtime", "cpu", "disk", "network"},
+		},
+		{
+			name: "processes",
+			cfg: config.Config{
+				PollInterval: time.Second,
+				Processes:    []config.Process{{Label: "api", Name: "api"}},
+			},
+			want: []string{"runtime", "memory", "cpu", "disk", "network", "process"},
+		},
+		{
+			name: "scripts",
+			cfg: config.Config{
+				PollInterval: time.Second,
+				Collectors:   map[string]config.Collector{"network": {Enabled: false}},
+				Scripts: []config.Script{
+					{Name: "queue", Command: "queue-depth"},
+					{Name: "backup", Command: "backup-age", Interval: time.Minute},
+				},
 			},
-			want: Service{
-				cfg: config.Config{},
-				log: newLogger(),
+			want: []string{"runtime", "memory", "cpu", "disk", "exec:queue", "exec:backup"},
+		},
+		{
+			name: "scrape",
+			cfg: config.Config{
+				PollInterval: time.Second,
+				Collectors:   map[string]config.Collector{"network": {Enabled: false}, "exec": {Enabled: false}},
+				Scripts:      []config.Script{{Name: "queue", Command: "queue-depth"}},
+				Scrape:       []config.Scrape{{Name: "app", URL: "http://localhost:9100/metrics"}},
 			},
+			want: []string{"runtime", "memory", "cpu", "disk", "prometheus:app"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, New(tt.args.cfg, tt.args.log), "New(%v, %v)", tt.args.cfg, tt.args.log)
+			s := New(tt.cfg, tt.tel, newLogger())
+			assert.Equal(t, tt.cfg, s.cfg)
+			assert.Equal(t, tt.want, s.registry.Names())
 		})
 	}
 }
+
+func TestService_Apply(t *testing.T) {
+	cfg := config.Config{
+		PollInterval:   time.Second,
+		ReportInterval: time.Second,
+		Collectors:     map[string]config.Collector{"exec": {Enabled: false}},
+		Scripts:        []config.Script{{Name: "queue", Command: "queue-depth"}},
+	}
+	s := New(cfg, nil, newLogger())
+	assert.Equal(t, []string{"runtime", "memory", "cpu", "disk", "network"}, s.registry.Names())
+
+	remote, err := cfg.WithRemote([]byte(`{"collectors":{"exec":{"enabled":true},"network":{"enabled":false}}}`))
+	require.NoError(t, err)
+	require.NoError(t, s.Apply(remote))
+	assert.Equal(t, []string{"runtime", "memory", "cpu", "disk", "exec:queue"}, s.registry.Names())
+
+	require.NoError(t, s.Apply(cfg))
+	assert.Equal(t, []string{"runtime", "memory", "cpu", "disk", "network"}, s.registry.Names())
+}
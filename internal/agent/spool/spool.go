@@ -0,0 +1,222 @@
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	fileExt = ".batch"
+	tmpExt  = ".tmp"
+)
+
+var (
+	ErrTooLarge = errors.New("batch is larger than spool max size")
+)
+
+// Spool ограниченная по размеру очередь неотправленных батчей на диске.
+// Каждый батч хранится в отдельном файле, имя которого задаёт порядок воспроизведения.
+type Spool struct {
+	dir     string
+	maxSize int64
+
+	// replayMu не даёт воспроизводить очередь из нескольких горутин одновременно.
+	replayMu sync.Mutex
+	mu       sync.Mutex
+	seq      uint64
+	entries  []entry
+	size     int64
+
+	dropped atomic.Int64
+}
+
+type entry struct {
+	seq  uint64
+	size int64
+}
+
+// New открывает очередь в каталоге dir, восстанавливая батчи, оставшиеся с прошлого запуска.
+func New(dir string, maxSize int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	s := &Spool{dir: dir, maxSize: maxSize}
+	for _, f := range files {
+		name := f.Name()
+		if strings.HasSuffix(name, tmpExt) {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if f.IsDir() || !strings.HasSuffix(name, fileExt) {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, fileExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		info, err := f.Info()
+		if err != nil {
+			return nil, fmt.Errorf("f.Info: %w", err)
+		}
+
+		s.entries = append(s.entries, entry{seq: seq, size: info.Size()})
+		s.size += info.Size()
+		if seq >= s.seq {
+			s.seq = seq + 1
+		}
+	}
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].seq < s.entries[j].seq })
+
+	return s, nil
+}
+
+// Push добавляет батч в конец очереди, вытесняя самые старые батчи при превышении размера.
+func (s *Spool) Push(data []byte) error {
+	size := int64(len(data))
+	if s.maxSize > 0 && size > s.maxSize {
+		s.dropped.Add(1)
+		return ErrTooLarge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.maxSize > 0 && s.size+size > s.maxSize && len(s.entries) != 0 {
+		if err := s.removeHead(); err != nil {
+			return err
+		}
+		s.dropped.Add(1)
+	}
+
+	seq := s.seq
+	path := s.path(seq)
+	if err := writeFile(path, data); err != nil {
+		return err
+	}
+
+	s.seq++
+	s.entries = append(s.entries, entry{seq: seq, size: size})
+	s.size += size
+
+	return nil
+}
+
+// Replay передаёт батчи в fn в порядке добавления и удаляет успешно обработанные.
+// Воспроизведение останавливается на первой ошибке fn, батч остаётся в очереди.
+func (s *Spool) Replay(fn func(data []byte) error) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	var replayed int
+	for {
+		s.mu.Lock()
+		if len(s.entries) == 0 {
+			s.mu.Unlock()
+			return replayed, nil
+		}
+		head := s.entries[0]
+
+		data, err := os.ReadFile(s.path(head.seq))
+		if err != nil {
+			// повреждённый батч не должен блокировать очередь.
+			_ = s.removeHead()
+			s.dropped.Add(1)
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Unlock()
+
+		if err = fn(data); err != nil {
+			return replayed, err
+		}
+
+		s.mu.Lock()
+		if len(s.entries) != 0 && s.entries[0].seq == head.seq {
+			err = s.removeHead()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+}
+
+// Len количество батчей в очереди.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Size размер очереди в байтах.
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
+
+// Dropped количество батчей, потерянных из-за переполнения или повреждения.
+func (s *Spool) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Spool) removeHead() error {
+	head := s.entries[0]
+	if err := os.Remove(s.path(head.seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+
+	s.entries = s.entries[1:]
+	s.size -= head.size
+
+	return nil
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, fileExt))
+}
+
+// writeFile атомарно записывает файл через временный файл и переименование.
+func writeFile(path string, data []byte) error {
+	tmp := path + tmpExt
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("file.Write: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("file.Sync: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("file.Close: %w", err)
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package spool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool_ReplayInOrder(t *testing.T) {
+	s, err := New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	for _, v := range []string{"first", "second", "third"} {
+		require.NoError(t, s.Push([]byte(v)))
+	}
+	assert.Equal(t, 3, s.Len())
+	assert.Equal(t, int64(len("first")+len("second")+len("third")), s.Size())
+
+	var got []string
+	n, err := s.Replay(func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"first", "second", "third"}, got)
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, int64(0), s.Size())
+}
+
+func TestSpool_ReplayStopsOnError(t *testing.T) {
+	s, err := New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Push([]byte("first")))
+	require.NoError(t, s.Push([]byte("second")))
+
+	errSend := errors.New("server unavailable")
+	n, err := s.Replay(func(data []byte) error {
+		if string(data) == "second" {
+			return errSend
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errSend)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestSpool_DropsOldestWhenFull(t *testing.T) {
+	s, err := New(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Push([]byte("aaaa")))
+	require.NoError(t, s.Push([]byte("bbbb")))
+	require.NoError(t, s.Push([]byte("cccc")))
+
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, int64(1), s.Dropped())
+
+	assert.ErrorIs(t, s.Push([]byte("larger than max")), ErrTooLarge)
+	assert.Equal(t, int64(2), s.Dropped())
+
+	var got []string
+	_, err = s.Replay(func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bbbb", "cccc"}, got)
+}
+
+func TestSpool_Restore(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := New(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Push([]byte("first")))
+	require.NoError(t, s.Push([]byte("second")))
+
+	s, err = New(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Len())
+
+	require.NoError(t, s.Push([]byte("third")))
+
+	var got []string
+	_, err = s.Replay(func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "third"}, got)
+}
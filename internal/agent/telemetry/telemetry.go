@@ -0,0 +1,197 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+)
+
+const (
+	// Prefix зарезервированный префикс имён собственных метрик агента.
+	Prefix = "Agent"
+	// CollectorName имя коллектора телеметрии, только он может отправлять метрики с префиксом Prefix.
+	CollectorName = "agent"
+)
+
+// Telemetry собственные метрики агента: отправки, ошибки, очереди и время работы коллекторов.
+// Telemetry является коллектором, поэтому её метрики отправляются вместе с остальными.
+// Методы nil-значения ничего не делают, чтобы телеметрию можно было не подключать.
+type Telemetry struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	gauges   map[string]metric.Metric
+	funcs    map[string]gaugeFunc
+}
+
+// counter накопленное значение и часть, уже переданная в Collect.
+type counter struct {
+	metric   metric.Metric
+	total    int64
+	reported int64
+}
+
+type gaugeFunc struct {
+	metric metric.Metric
+	fn     func() float64
+}
+
+func New() *Telemetry {
+	return &Telemetry{
+		counters: make(map[string]*counter),
+		gauges:   make(map[string]metric.Metric),
+		funcs:    make(map[string]gaugeFunc),
+	}
+}
+
+// BatchSent учитывает успешно отправленный батч и время его отправки.
+func (t *Telemetry) BatchSent(latency time.Duration) {
+	t.add("BatchesSent", nil, 1)
+	t.set("SendLatencyMs", nil, float64(latency)/float64(time.Millisecond))
+}
+
+// SendFailed учитывает неудачную отправку батча с причиной reason.
+func (t *Telemetry) SendFailed(reason string) {
+	t.add("SendFailures", map[string]string{"reason": reason}, 1)
+}
+
+// Dropped учитывает n метрик, которые не удалось ни отправить, ни сохранить.
+func (t *Telemetry) Dropped(n int) {
+	t.add("DroppedMetrics", nil, int64(n))
+}
+
+// Suppressed учитывает n метрик, не отправленных из-за отсутствия изменений.
+func (t *Telemetry) Suppressed(n int) {
+	t.add("SuppressedMetrics", nil, int64(n))
+}
+
+// ObserveCollect учитывает время опроса коллектора и ошибку опроса.
+func (t *Telemetry) ObserveCollect(name string, d time.Duration, err error) {
+	labels := map[string]string{"collector": name}
+	t.set("CollectDurationMs", labels, float64(d)/float64(time.Millisecond))
+	if err != nil {
+		t.add("CollectErrors", labels, 1)
+	}
+}
+
+// Gauge регистрирует метрику name, значение которой вычисляется fn при каждом опросе.
+func (t *Telemetry) Gauge(name string, fn func() float64) {
+	if t == nil {
+		return
+	}
+
+	m := metric.NewGauge(Prefix+name, 0)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.funcs[m.ID()] = gaugeFunc{metric: m, fn: fn}
+}
+
+func (t *Telemetry) Name() string {
+	return CollectorName
+}
+
+// Collect возвращает текущие значения gauge-метрик и приращения счётчиков с прошлого вызова.
+func (t *Telemetry) Collect(_ context.Context) ([]metric.Metric, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	res := t.gaugesLocked(now)
+	for _, v := range t.counters {
+		m := v.metric
+		m.Delta = v.total - v.reported
+		m.Timestamp = now
+		v.reported = v.total
+		res = append(res, m)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ID() < res[j].ID() })
+
+	return res, nil
+}
+
+// Handler отдаёт текущие значения метрик в JSON, счётчики отдаются накопленными с момента запуска.
+func (t *Telemetry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		res := make(map[string]float64)
+
+		t.mu.Lock()
+		for _, v := range t.gaugesLocked(time.Now()) {
+			res[v.ID()] = v.Value
+		}
+		for _, v := range t.counters {
+			res[v.metric.ID()] = float64(v.total)
+		}
+		t.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
+
+func (t *Telemetry) gaugesLocked(now time.Time) []metric.Metric {
+	res := make([]metric.Metric, 0, len(t.gauges)+len(t.funcs))
+	for _, v := range t.gauges {
+		res = append(res, v)
+	}
+	for _, v := range t.funcs {
+		m := v.metric
+		m.Value = v.fn()
+		m.Timestamp = now
+		res = append(res, m)
+	}
+
+	return res
+}
+
+func (t *Telemetry) add(name string, labels map[string]string, delta int64) {
+	if t == nil {
+		return
+	}
+
+	m := metric.NewCounter(Prefix+name, 0)
+	if labels != nil {
+		m = m.WithLabels(labels)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.counters[m.ID()]
+	if !ok {
+		c = &counter{metric: m}
+		t.counters[m.ID()] = c
+	}
+	c.total += delta
+}
+
+func (t *Telemetry) set(name string, labels map[string]string, value float64) {
+	if t == nil {
+		return
+	}
+
+	m := metric.NewGauge(Prefix+name, value)
+	if labels != nil {
+		m = m.WithLabels(labels)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.gauges[m.ID()] = m
+}
@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, tel *Telemetry) map[string]metric.Metric {
+	t.Helper()
+
+	metrics, err := tel.Collect(context.Background())
+	require.NoError(t, err)
+
+	res := make(map[string]metric.Metric, len(metrics))
+	for _, v := range metrics {
+		res[v.ID()] = v
+	}
+
+	return res
+}
+
+func TestTelemetry_Collect(t *testing.T) {
+	tel := New()
+
+	depth := 3.0
+	tel.Gauge("QueueDepth", func() float64 { return depth })
+
+	tel.BatchSent(250 * time.Millisecond)
+	tel.BatchSent(100 * time.Millisecond)
+	tel.SendFailed("status_5xx")
+	tel.SendFailed("timeout")
+	tel.SendFailed("timeout")
+	tel.Dropped(10)
+	tel.ObserveCollect("cpu", 2*time.Millisecond, nil)
+	tel.ObserveCollect("disk", time.Millisecond, errors.New("permission denied"))
+
+	got := collect(t, tel)
+	assert.Equal(t, int64(2), got["AgentBatchesSent"].Delta)
+	assert.Equal(t, metric.KindCounter, got["AgentBatchesSent"].Kind)
+	assert.Equal(t, 100.0, got["AgentSendLatencyMs"].Value)
+	assert.Equal(t, int64(1), got[`AgentSendFailures{reason="status_5xx"}`].Delta)
+	assert.Equal(t, int64(2), got[`AgentSendFailures{reason="timeout"}`].Delta)
+	assert.Equal(t, int64(10), got["AgentDroppedMetrics"].Delta)
+	assert.Equal(t, 2.0, got[`AgentCollectDurationMs{collector="cpu"}`].Value)
+	assert.Equal(t, int64(1), got[`AgentCollectErrors{collector="disk"}`].Delta)
+	assert.NotContains(t, got, `AgentCollectErrors{collector="cpu"}`)
+	assert.Equal(t, 3.0, got["AgentQueueDepth"].Value)
+
+	// повторный опрос возвращает только новые приращения.
+	depth = 0
+	tel.BatchSent(time.Millisecond)
+
+	got = collect(t, tel)
+	assert.Equal(t, int64(1), got["AgentBatchesSent"].Delta)
+	assert.Equal(t, int64(0), got[`AgentSendFailures{reason="timeout"}`].Delta)
+	assert.Equal(t, 0.0, got["AgentQueueDepth"].Value)
+}
+
+func TestTelemetry_Handler(t *testing.T) {
+	tel := New()
+	tel.BatchSent(time.Millisecond)
+	tel.SendFailed("network")
+
+	_, err := tel.Collect(context.Background())
+	require.NoError(t, err)
+	tel.BatchSent(time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	tel.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got map[string]float64
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, 2.0, got["AgentBatchesSent"], "handler reports totals")
+	assert.Equal(t, 1.0, got[`AgentSendFailures{reason="network"}`])
+
+	rec = httptest.NewRecorder()
+	tel.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestTelemetry_Nil(t *testing.T) {
+	var tel *Telemetry
+
+	assert.NotPanics(t, func() {
+		tel.BatchSent(time.Millisecond)
+		tel.SendFailed("other")
+		tel.Dropped(1)
+		tel.ObserveCollect("cpu", time.Millisecond, nil)
+		tel.Gauge("QueueDepth", func() float64 { return 0 })
+	})
+
+	metrics, err := tel.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, metrics)
+}
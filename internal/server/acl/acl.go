@@ -0,0 +1,155 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+var (
+	ErrInvalidAddr = errors.New("invalid client address")
+)
+
+// ACL сетевой список доступа с поддержкой IPv4 и IPv6.
+type ACL struct {
+	// подсети, из которых разрешён доступ, если пусто - разрешены все, кроме deny.
+	allow []netip.Prefix
+	// подсети, из которых доступ запрещён, имеют приоритет над allow.
+	deny []netip.Prefix
+	// подсети прокси, заголовкам X-Forwarded-For и X-Real-IP которых можно доверять.
+	proxies []netip.Prefix
+}
+
+// New создаёт список доступа из подсетей в формате CIDR или отдельных адресов.
+func New(allow, deny, trustedProxies []string) (*ACL, error) {
+	var a ACL
+	var err error
+
+	if a.allow, err = parsePrefixes(allow); err != nil {
+		return nil, fmt.Errorf("allow: %w", err)
+	}
+	if a.deny, err = parsePrefixes(deny); err != nil {
+		return nil, fmt.Errorf("deny: %w", err)
+	}
+	if a.proxies, err = parsePrefixes(trustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+
+	return &a, nil
+}
+
+// Enabled сообщает, ограничивает ли список доступ.
+func (a *ACL) Enabled() bool {
+	return a != nil && (len(a.allow) != 0 || len(a.deny) != 0)
+}
+
+// Allowed проверяет, разрешён ли доступ с адреса.
+func (a *ACL) Allowed(addr netip.Addr) bool {
+	if !a.Enabled() {
+		return true
+	}
+
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return false
+	}
+
+	if contains(a.deny, addr) {
+		return false
+	}
+
+	return len(a.allow) == 0 || contains(a.allow, addr)
+}
+
+// ClientIP определяет адрес клиента по адресу соединения и, если соединение
+// пришло от доверенного прокси, по заголовкам X-Forwarded-For и X-Real-IP.
+func (a *ACL) ClientIP(remoteAddr, forwardedFor, realIP string) (netip.Addr, error) {
+	remote, err := ParseAddr(remoteAddr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if a == nil || !contains(a.proxies, remote) {
+		return remote, nil
+	}
+
+	if forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := ParseAddr(hops[i])
+			if err != nil {
+				return netip.Addr{}, err
+			}
+
+			if !contains(a.proxies, hop) {
+				return hop, nil
+			}
+		}
+	}
+
+	if realIP != "" {
+		return ParseAddr(realIP)
+	}
+
+	return remote, nil
+}
+
+// ParseAddr разбирает адрес с портом или без него.
+func ParseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: %q", ErrInvalidAddr, s)
+	}
+
+	return addr.Unmap(), nil
+}
+
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	res := make([]netip.Prefix, 0, len(list))
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("netip.ParseAddr: %w", err)
+			}
+			addr = addr.Unmap()
+			res = append(res, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("netip.ParsePrefix: %w", err)
+		}
+		res = append(res, prefix.Masked())
+	}
+
+	return res, nil
+}
+
+func contains(list []netip.Prefix, addr netip.Addr) bool {
+	for _, v := range list {
+		if v.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
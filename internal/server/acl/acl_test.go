@@ -0,0 +1,121 @@
+package acl
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACL_Allowed(t *testing.T) {
+	a, err := New(
+		[]string{"192.168.0.0/16", "2001:db8::/32", "10.0.0.1"},
+		[]string{"192.168.1.0/24", "2001:db8:dead::/48"},
+		nil,
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		addr string
+		want bool
+	}{
+		{name: "allowed ipv4", addr: "192.168.2.10", want: true},
+		{name: "denied ipv4", addr: "192.168.1.10", want: false},
+		{name: "single address", addr: "10.0.0.1", want: true},
+		{name: "outside allow", addr: "10.0.0.2", want: false},
+		{name: "ipv4 mapped ipv6", addr: "::ffff:192.168.2.10", want: true},
+		{name: "allowed ipv6", addr: "2001:db8:1::1", want: true},
+		{name: "denied ipv6", addr: "2001:db8:dead::1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, a.Allowed(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestACL_AllowedDenyOnly(t *testing.T) {
+	a, err := New(nil, []string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	assert.False(t, a.Allowed(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, a.Allowed(netip.MustParseAddr("172.16.0.1")))
+}
+
+func TestACL_Disabled(t *testing.T) {
+	var a *ACL
+	assert.True(t, a.Allowed(netip.MustParseAddr("10.1.2.3")))
+
+	a, err := New(nil, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, a.Enabled())
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New([]string{"not-a-subnet"}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestACL_ClientIP(t *testing.T) {
+	a, err := New(nil, nil, []string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:       "direct connection ignores headers",
+			remoteAddr: "192.168.1.5:5555",
+			realIP:     "10.1.1.1",
+			want:       "192.168.1.5",
+		},
+		{
+			name:         "trusted proxy forwarded for",
+			remoteAddr:   "10.0.0.1:5555",
+			forwardedFor: "203.0.113.7, 10.0.0.2",
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "spoofed left hop is skipped",
+			remoteAddr:   "10.0.0.1:5555",
+			forwardedFor: "1.1.1.1, 203.0.113.7",
+			want:         "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy real ip",
+			remoteAddr: "[fd00::1]:5555",
+			realIP:     "2001:db8::7",
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.1:5555",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "invalid real ip",
+			remoteAddr: "10.0.0.1:5555",
+			realIP:     "localhost:8080",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.ClientIP(tt.remoteAddr, tt.forwardedFor, tt.realIP)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAddr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, netip.MustParseAddr(tt.want), got)
+		})
+	}
+}
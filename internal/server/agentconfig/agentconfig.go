@@ -0,0 +1,93 @@
+package agentconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotConfigured = errors.New("agent config is not configured")
+	ErrInvalidConfig = errors.New("agent config must be a JSON object")
+)
+
+// Store удалённая конфигурация агентов из JSON файла.
+// Файл перечитывается при изменении, поэтому новая конфигурация раздаётся без перезапуска сервера.
+// Содержимое передаётся агентам как есть, его разбирает агент.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	data    []byte
+	version string
+}
+
+// New создаёт хранилище конфигурации из файла path, пустой path отключает раздачу конфигурации.
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// Enabled сообщает, раздаёт ли сервер конфигурацию агентов.
+func (s *Store) Enabled() bool {
+	return s != nil && s.path != ""
+}
+
+// Get возвращает конфигурацию и её версию - SHA-256 содержимого в hex.
+// Если файл не удалось прочитать или он некорректен, возвращается последняя корректная версия.
+func (s *Store) Get() ([]byte, string, error) {
+	if !s.Enabled() {
+		return nil, "", ErrNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.reload()
+	if err != nil && s.data == nil {
+		return nil, "", err
+	}
+
+	return s.data, s.version, nil
+}
+
+// reload перечитывает файл, если изменились время модификации или размер.
+func (s *Store) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("os.Stat: %w", err)
+	}
+	if s.data != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var obj map[string]json.RawMessage
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	var buf bytes.Buffer
+	if err = json.Compact(&buf, data); err != nil {
+		return fmt.Errorf("json.Compact: %w", err)
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	s.data = buf.Bytes()
+	s.version = hex.EncodeToString(sum[:])
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package agentconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Get(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"report_interval": "10s"}`), 0600))
+
+	s := New(path)
+	data, version, err := s.Get()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"report_interval":"10s"}`, string(data))
+	assert.Len(t, version, 64)
+
+	_, same, err := s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, version, same)
+
+	// новое содержимое файла даёт новую версию.
+	require.NoError(t, os.WriteFile(path, []byte(`{"report_interval": "30s"}`), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	data, changed, err := s.Get()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"report_interval":"30s"}`, string(data))
+	assert.NotEqual(t, version, changed)
+
+	// некорректный файл не заменяет последнюю корректную конфигурацию.
+	require.NoError(t, os.WriteFile(path, []byte(`[1, 2]`), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	_, kept, err := s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, changed, kept)
+}
+
+func TestStore_GetErrors(t *testing.T) {
+	_, _, err := New("").Get()
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	var nilStore *Store
+	_, _, err = nilStore.Get()
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	_, _, err = New(filepath.Join(t.TempDir(), "missing.json")).Get()
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "agent.json")
+	require.NoError(t, os.WriteFile(path, []byte(`"text"`), 0600))
+	_, _, err = New(path).Get()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -11,17 +11,23 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/1Asi1/metric-track.git/internal/server/acl"
+	"github.com/1Asi1/metric-track.git/internal/server/agentconfig"
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
 	"github.com/1Asi1/metric-track.git/internal/server/config"
 	"github.com/1Asi1/metric-track.git/internal/server/repository/memory"
 	"github.com/1Asi1/metric-track.git/internal/server/repository/storage"
 	"github.com/1Asi1/metric-track.git/internal/server/service"
 	metric_grpc "github.com/1Asi1/metric-track.git/internal/server/transport/grpc"
 	"github.com/1Asi1/metric-track.git/internal/server/transport/rest"
+	"github.com/1Asi1/metric-track.git/internal/server/transport/rest/middleware"
 	"github.com/1Asi1/metric-track.git/internal/server/transport/rest/v1"
 	proto "github.com/1Asi1/metric-track.git/rpc/gen"
 	"github.com/go-chi/chi/v5"
 	midlog "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 )
 
@@ -69,11 +75,35 @@ func (s *APIServer) Run() error {
 		store = memory.New(s.log, s.cfg)
 	}
 
+	creds := make([]auth.Credential, len(s.cfg.Agents))
+	for i, v := range s.cfg.Agents {
+		roles := make([]auth.Role, len(v.Roles))
+		for j, r := range v.Roles {
+			roles[j] = auth.Role(r)
+		}
+
+		creds[i] = auth.Credential{
+			Token:     v.Token,
+			Tenant:    v.Tenant,
+			SecretKey: v.SecretKey,
+			Roles:     roles,
+		}
+	}
+	agentCreds := auth.NewCredentials(creds)
+
+	networkACL, err := acl.New(s.cfg.AllowSubnets, s.cfg.DenySubnets, s.cfg.TrustedProxies)
+	if err != nil {
+		l.Error().Err(err).Msg("acl.New")
+		return err
+	}
+
 	metricS := service.New(store, s.log)
-	route := rest.New(s.mux, metricS, s.log)
+	agentConfig := agentconfig.New(s.cfg.AgentConfig)
+	route := rest.New(s.mux, metricS, agentConfig, s.log)
 
 	route.Mux.Use(midlog.Logger)
-	v1.New(route, s.cfg.SecretKey, s.cfg.CryptoKey)
+	route.Mux.Use(middleware.CheckSubnetMiddleware(networkACL))
+	v1.New(route, s.cfg.SecretKey, s.cfg.CryptoKey, agentCreds)
 
 	var srv = http.Server{Addr: s.cfg.MetricServerAddr}
 	sigint := make(chan os.Signal, 1)
@@ -103,10 +133,14 @@ func (s *APIServer) Run() error {
 		}
 
 		grpcServer := grpc.NewServer(
-			grpc.UnaryInterceptor(metric_grpc.CheckSubnetInterceptor(s.cfg.TrustedSubnet)),
-			grpc.UnaryInterceptor(metric_grpc.HMACInterceptor(s.cfg.SecretKey)),
+			grpc.ChainUnaryInterceptor(
+				metric_grpc.CheckSubnetInterceptor(networkACL),
+				metric_grpc.AuthInterceptor(agentCreds),
+				metric_grpc.RoleInterceptor(agentCreds),
+				metric_grpc.HMACInterceptor(s.cfg.SecretKey),
+			),
 		)
-		proto.RegisterMetricGrpcServer(grpcServer, metric_grpc.NewMetricGrpcServer(metricS))
+		proto.RegisterMetricGrpcServer(grpcServer, metric_grpc.NewMetricGrpcServer(metricS, agentConfig))
 
 		if err = grpcServer.Serve(grpcConn); err != nil {
 			l.Err(err).Msgf("grpcServer.Serve error: %v; GrpcPort: %v", err, s.cfg.GrpcPort)
@@ -114,7 +148,8 @@ func (s *APIServer) Run() error {
 	}()
 
 	l.Info().Msgf("server start: http://%s", s.cfg.MetricServerAddr)
-	srv.Handler = route.Mux
+	// h2c принимает HTTP/2 без TLS от агентов с включённым HTTP2, запросы HTTP/1.1 обрабатываются как раньше.
+	srv.Handler = h2c.NewHandler(route.Mux, &http2.Server{})
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 		l.Error().Err(err).Msg("http.ListenAndServe")
 		return err
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// Роли агентов.
+const (
+	// RoleIngest право на загрузку метрик.
+	RoleIngest Role = "ingest"
+	// RoleRead право на чтение метрик.
+	RoleRead Role = "read"
+	// RoleAdmin все права.
+	RoleAdmin Role = "admin"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Role роль, определяющая доступные агенту операции.
+type Role string
+
+// Credential учётные данные агента, хранящиеся на сервере.
+type Credential struct {
+	// токен, который агент передаёт в заголовке Authorization.
+	Token string
+	// тенант, в пространство имён которого попадают метрики агента.
+	Tenant string
+	// ключ подписи HMAC агента, если пустой - используется общий ключ сервера.
+	SecretKey string
+	// роли агента, если не заданы - только RoleIngest.
+	Roles []Role
+}
+
+// HasRole сообщает, разрешена ли агенту операция с указанной ролью.
+func (c Credential) HasRole(role Role) bool {
+	if len(c.Roles) == 0 {
+		return role == RoleIngest
+	}
+
+	for _, v := range c.Roles {
+		if v == role || v == RoleAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Credentials учётные данные агентов по токену.
+type Credentials map[string]Credential
+
+type ctxKey struct{}
+
+func NewCredentials(list []Credential) Credentials {
+	res := make(Credentials, len(list))
+	for _, v := range list {
+		res[v.Token] = v
+	}
+
+	return res
+}
+
+// Enabled сообщает, настроена ли аутентификация агентов.
+func (c Credentials) Enabled() bool {
+	return len(c) != 0
+}
+
+// Authenticate находит учётные данные по значению заголовка Authorization.
+func (c Credentials) Authenticate(header string) (Credential, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(header), bearerPrefix))
+	if token == "" {
+		return Credential{}, ErrUnauthorized
+	}
+
+	cred, ok := c[token]
+	if !ok {
+		return Credential{}, ErrUnauthorized
+	}
+
+	return cred, nil
+}
+
+// Authorize проверяет, что агент из контекста имеет указанную роль.
+func (c Credentials) Authorize(ctx context.Context, role Role) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	cred, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	if !cred.HasRole(role) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// NewContext возвращает копию контекста с учётными данными агента.
+func NewContext(ctx context.Context, cred Credential) context.Context {
+	return context.WithValue(ctx, ctxKey{}, cred)
+}
+
+// FromContext возвращает учётные данные агента из контекста.
+func FromContext(ctx context.Context) (Credential, bool) {
+	cred, ok := ctx.Value(ctxKey{}).(Credential)
+	return cred, ok
+}
+
+// SecretKey возвращает ключ подписи агента из контекста или ключ по умолчанию.
+func SecretKey(ctx context.Context, defaultKey string) string {
+	cred, ok := FromContext(ctx)
+	if !ok || cred.SecretKey == "" {
+		return defaultKey
+	}
+
+	return cred.SecretKey
+}
@@ -6,20 +6,34 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 type ConfigFile struct {
-	MetricServerAddr string `json:"address"`
-	StoreInterval    string `json:"store_interval"`
-	StorePath        string `json:"store_file"`
-	StoreRestore     bool   `json:"restore"`
-	PostgresConnDSN  string `json:"database_dsn"`
-	CryptoKey        string `json:"crypto_key"`
-	TrustedSubnet    string `json:"trusted_subnet"`
-	GrpcPort         string `json:"grpc_port"`
+	MetricServerAddr string   `json:"address"`
+	StoreInterval    string   `json:"store_interval"`
+	StorePath        string   `json:"store_file"`
+	StoreRestore     bool     `json:"restore"`
+	PostgresConnDSN  string   `json:"database_dsn"`
+	CryptoKey        string   `json:"crypto_key"`
+	TrustedSubnet    string   `json:"trusted_subnet"`
+	AllowSubnets     []string `json:"allow_subnets"`
+	DenySubnets      []string `json:"deny_subnets"`
+	TrustedProxies   []string `json:"trusted_proxies"`
+	GrpcPort         string   `json:"grpc_port"`
+	Agents           []Agent  `json:"agents"`
+	AgentConfig      string   `json:"agent_config"`
+}
+
+// Agent учётные данные агента из файла конфигурации.
+type Agent struct {
+	Token     string   `json:"token"`
+	Tenant    string   `json:"tenant"`
+	SecretKey string   `json:"secret_key"`
+	Roles     []string `json:"roles"`
 }
 
 type Config struct {
@@ -31,8 +45,13 @@ type Config struct {
 	PostgresConnDSN  string
 	SecretKey        string
 	CryptoKey        string
-	TrustedSubnet    string
+	AllowSubnets     []string
+	DenySubnets      []string
+	TrustedProxies   []string
 	GrpcPort         string
+	Agents           []Agent
+	// путь к файлу удалённой конфигурации агентов, если пустой - конфигурация не раздаётся.
+	AgentConfig string
 }
 
 func New(log zerolog.Logger) (Config, error) {
@@ -47,8 +66,11 @@ func New(log zerolog.Logger) (Config, error) {
 	postgresql := flag.String("d", "", "dsn connecting to postgres")
 	key := flag.String("k", "", "secret key for server")
 	cryptoKey := flag.String("crypto-key", "", "crypto key for agent")
-	trusted := flag.String("t", "", "trusted-subnet")
+	trusted := flag.String("t", "", "trusted subnets, comma separated")
+	deny := flag.String("deny-subnets", "", "denied subnets, comma separated")
+	proxies := flag.String("trusted-proxies", "", "trusted proxies subnets, comma separated")
 	grpc := flag.String("g", ":8083", "grpc port")
+	agentConfig := flag.String("agent-config", "", "path to remote agent configuration")
 	flag.Parse()
 
 	var cfgPathName string
@@ -155,11 +177,31 @@ func New(log zerolog.Logger) (Config, error) {
 
 	trustedSubnetEnv, ok := os.LookupEnv("TRUSTED_SUBNET")
 	if ok {
-		cfg.TrustedSubnet = trustedSubnetEnv
+		cfg.AllowSubnets = splitList(trustedSubnetEnv)
+	} else {
+		cfg.AllowSubnets = splitList(*trusted)
+		if len(cfg.AllowSubnets) == 0 {
+			cfg.AllowSubnets = append(splitList(cfgFileData.TrustedSubnet), cfgFileData.AllowSubnets...)
+		}
+	}
+
+	denySubnetsEnv, ok := os.LookupEnv("DENY_SUBNETS")
+	if ok {
+		cfg.DenySubnets = splitList(denySubnetsEnv)
+	} else {
+		cfg.DenySubnets = splitList(*deny)
+		if len(cfg.DenySubnets) == 0 {
+			cfg.DenySubnets = cfgFileData.DenySubnets
+		}
+	}
+
+	trustedProxiesEnv, ok := os.LookupEnv("TRUSTED_PROXIES")
+	if ok {
+		cfg.TrustedProxies = splitList(trustedProxiesEnv)
 	} else {
-		cfg.TrustedSubnet = *trusted
-		if cfg.TrustedSubnet == "" {
-			cfg.TrustedSubnet = cfgFileData.TrustedSubnet
+		cfg.TrustedProxies = splitList(*proxies)
+		if len(cfg.TrustedProxies) == 0 {
+			cfg.TrustedProxies = cfgFileData.TrustedProxies
 		}
 	}
 
@@ -173,6 +215,18 @@ func New(log zerolog.Logger) (Config, error) {
 		}
 	}
 
+	cfg.Agents = cfgFileData.Agents
+
+	agentConfigEnv, ok := os.LookupEnv("AGENT_CONFIG")
+	if ok {
+		cfg.AgentConfig = agentConfigEnv
+	} else {
+		cfg.AgentConfig = *agentConfig
+		if cfg.AgentConfig == "" {
+			cfg.AgentConfig = cfgFileData.AgentConfig
+		}
+	}
+
 	l.Info().Msgf("store restore: %v", *restore)
 	cfg.StoreRestore = *restore
 	if !cfg.StoreRestore {
@@ -181,3 +235,14 @@ func New(log zerolog.Logger) (Config, error) {
 
 	return cfg, nil
 }
+
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
@@ -1,7 +1,57 @@
 package models
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
 type Metric struct {
+	Tenant  string   `db:"tenant"`
 	ID      string   `db:"id"`
 	Gauge   *float64 `db:"gauge"`
 	Counter *int64   `db:"counter"`
+	Tags    Tags     `db:"tags"`
+}
+
+// Tags метки метрики, хранятся в колонке jsonb.
+type Tags map[string]string
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func (t *Tags) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported tags type %T", src)
+	}
+
+	var res Tags
+	if err := json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if len(res) == 0 {
+		res = nil
+	}
+	*t = res
+
+	return nil
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags_ValueScan(t *testing.T) {
+	tags := Tags{"host": "web-1", "env": "prod"}
+
+	v, err := tags.Value()
+	require.NoError(t, err)
+
+	var got Tags
+	require.NoError(t, got.Scan([]byte(v.(string))))
+	assert.Equal(t, tags, got)
+
+	empty, err := Tags(nil).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "{}", empty)
+
+	require.NoError(t, got.Scan("{}"))
+	assert.Nil(t, got)
+
+	assert.Error(t, got.Scan(42))
+}
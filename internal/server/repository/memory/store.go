@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/1Asi1/metric-track.git/internal/server/config"
+	"github.com/1Asi1/metric-track.git/internal/server/tenant"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -28,7 +30,10 @@ type Store interface {
 }
 
 type StoreMemory struct {
-	metric map[string]Type
+	// mu защищает метрики от одновременных запросов, указатель общий для копий StoreMemory.
+	mu *sync.RWMutex
+	// метрики по тенантам.
+	metric map[string]map[string]Type
 	log    zerolog.Logger
 }
 
@@ -40,21 +45,26 @@ type FileStore struct {
 }
 
 type Metric struct {
-	Name  string   `json:"name"`
-	Value *float64 `json:"value"`
-	Delta *int64   `json:"delta"`
+	Tenant string   `json:"tenant,omitempty"`
+	Name   string   `json:"name"`
+	Value  *float64 `json:"value"`
+	Delta  *int64   `json:"delta"`
+	// метки агента, отправившего метрику.
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 type Type struct {
 	Gauge   *float64
 	Counter *int64
+	Tags    map[string]string
 }
 
 func New(log zerolog.Logger, cfg config.Config) Store {
 	l := log.With().Str("memory", "New").Logger()
 
 	store := StoreMemory{
-		metric: make(map[string]Type),
+		mu:     &sync.RWMutex{},
+		metric: make(map[string]map[string]Type),
 		log:    log,
 	}
 
@@ -86,21 +96,39 @@ func New(log zerolog.Logger, cfg config.Config) Store {
 	return store
 }
 
+// Get возвращает копию метрик тенанта, изменения копии сохраняются через Update.
 func (m StoreMemory) Get(ctx context.Context) (map[string]Type, error) {
-	return m.metric, nil
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metric := m.metric[tenant.FromContext(ctx)]
+	res := make(map[string]Type, len(metric))
+	for k, v := range metric {
+		res[k] = v
+	}
+
+	return res, nil
 }
 
 func (m StoreMemory) GetOne(ctx context.Context, name string) (Type, error) {
-	if _, ok := m.metric[name]; !ok {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, ok := m.metric[tenant.FromContext(ctx)][name]
+	if !ok {
 		return Type{}, fmt.Errorf("problem with m.metric[%s]: %w", name, ErrNotFound)
 	}
 
-	return m.metric[name], nil
+	return v, nil
 }
 
 func (m StoreMemory) Update(ctx context.Context, name string, data map[string]Type) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metric := m.tenantMetric(ctx)
 	for k, v := range data {
-		m.metric[k] = v
+		metric[k] = v
 	}
 }
 
@@ -109,26 +137,41 @@ func (m StoreMemory) Ping() error {
 }
 
 func (m StoreMemory) Updates(ctx context.Context, req []Metric) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metric := m.tenantMetric(ctx)
+	for _, v := range req {
+		metric[v.Name] = Type{Gauge: v.Value, Counter: v.Delta, Tags: v.Tags}
+	}
+
 	return nil
 }
 
+// tenantMetric возвращает метрики тенанта из контекста, создавая пространство имён при первой записи.
+// Вызывается под m.mu на запись, чтения не создают тенантов.
+func (m StoreMemory) tenantMetric(ctx context.Context) map[string]Type {
+	id := tenant.FromContext(ctx)
+	metric, ok := m.metric[id]
+	if !ok {
+		metric = make(map[string]Type)
+		m.metric[id] = metric
+	}
+
+	return metric
+}
+
 func (f FileStore) Get(ctx context.Context) (map[string]Type, error) {
-	return f.memoryStore.metric, nil
+	return f.memoryStore.Get(ctx)
 }
 
 func (f FileStore) GetOne(ctx context.Context, name string) (Type, error) {
-	if _, ok := f.memoryStore.metric[name]; !ok {
-		return Type{}, fmt.Errorf("problem with m.metric[%s]: %w", name, ErrNotFound)
-	}
-
-	return f.memoryStore.metric[name], nil
+	return f.memoryStore.GetOne(ctx, name)
 }
 
 func (f FileStore) Update(ctx context.Context, name string, data map[string]Type) {
 	l := f.memoryStore.log.With().Str("memory", "Update").Logger()
-	for k, v := range data {
-		f.memoryStore.metric[k] = v
-	}
+	f.memoryStore.Update(ctx, name, data)
 
 	err := f.dataRetention()
 	if err != nil {
@@ -141,7 +184,11 @@ func (f FileStore) Ping() error {
 }
 
 func (f FileStore) Updates(ctx context.Context, req []Metric) error {
-	return nil
+	if err := f.memoryStore.Updates(ctx, req); err != nil {
+		return err
+	}
+
+	return f.dataRetention()
 }
 
 func (f FileStore) dataRetentionPeriodic() {
@@ -191,18 +238,24 @@ func (f FileStore) dataRetention() error {
 }
 
 func (f FileStore) toData() ([]byte, error) {
+	f.memoryStore.mu.RLock()
+	defer f.memoryStore.mu.RUnlock()
+
 	var metrics []Metric
-	if len(f.memoryStore.metric) != 0 {
-		for n, v := range f.memoryStore.metric {
+	for t, data := range f.memoryStore.metric {
+		for n, v := range data {
 			metric := Metric{
-				Name:  n,
-				Value: v.Gauge,
-				Delta: v.Counter,
+				Tenant: t,
+				Name:   n,
+				Value:  v.Gauge,
+				Delta:  v.Counter,
+				Tags:   v.Tags,
 			}
 
 			metrics = append(metrics, metric)
 		}
-	} else {
+	}
+	if len(metrics) == 0 {
 		return nil, nil
 	}
 
@@ -214,7 +267,7 @@ func (f FileStore) toData() ([]byte, error) {
 	return data, nil
 }
 
-func getData(pathFile string, log zerolog.Logger) (map[string]Type, error) {
+func getData(pathFile string, log zerolog.Logger) (map[string]map[string]Type, error) {
 	file, err := os.OpenFile(pathFile, os.O_RDONLY|os.O_CREATE, 0666)
 	if err != nil {
 		return nil, err
@@ -226,7 +279,7 @@ func getData(pathFile string, log zerolog.Logger) (map[string]Type, error) {
 		data = append(data, scanner.Bytes()...)
 	}
 
-	metric := make(map[string]Type)
+	metric := make(map[string]map[string]Type)
 	if len(data) != 0 {
 		var metrics []Metric
 		err = json.Unmarshal(data, &metrics)
@@ -235,9 +288,13 @@ func getData(pathFile string, log zerolog.Logger) (map[string]Type, error) {
 		}
 
 		for _, v := range metrics {
-			metric[v.Name] = Type{
+			if _, ok := metric[v.Tenant]; !ok {
+				metric[v.Tenant] = make(map[string]Type)
+			}
+			metric[v.Tenant][v.Name] = Type{
 				Gauge:   v.Value,
 				Counter: v.Delta,
+				Tags:    v.Tags,
 			}
 		}
 	}
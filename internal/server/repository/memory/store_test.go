@@ -2,11 +2,16 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/1Asi1/metric-track.git/internal/server/config"
+	"github.com/1Asi1/metric-track.git/internal/server/tenant"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -53,7 +58,8 @@ func TestFileStore_Get(t *testing.T) {
 			name: "positive",
 			fields: fields{
 				memoryStore: StoreMemory{
-					metric: data,
+					mu:     &sync.RWMutex{},
+					metric: map[string]map[string]Type{tenant.Default: data},
 					log:    newLogger(),
 				},
 				storeRestore:  false,
@@ -118,7 +124,8 @@ func TestFileStore_GetOne(t *testing.T) {
 			name: "positive",
 			fields: fields{
 				memoryStore: StoreMemory{
-					metric: data,
+					mu:     &sync.RWMutex{},
+					metric: map[string]map[string]Type{tenant.Default: data},
 					log:    newLogger(),
 				},
 				storeRestore:  false,
@@ -172,7 +179,8 @@ func TestFileStore_Ping(t *testing.T) {
 			name: "positive",
 			fields: fields{
 				memoryStore: StoreMemory{
-					metric: make(map[string]Type),
+					mu:     &sync.RWMutex{},
+					metric: make(map[string]map[string]Type),
 					log:    newLogger(),
 				},
 				storeRestore:  false,
@@ -226,7 +234,8 @@ func TestFileStore_Update(t *testing.T) {
 			name: "positive",
 			fields: fields{
 				memoryStore: StoreMemory{
-					metric: data,
+					mu:     &sync.RWMutex{},
+					metric: map[string]map[string]Type{tenant.Default: data},
 					log:    newLogger(),
 				},
 				storeRestore:  false,
@@ -286,7 +295,8 @@ func TestFileStore_Updates(t *testing.T) {
 			name: "positive",
 			fields: fields{
 				memoryStore: StoreMemory{
-					metric: data,
+					mu:     &sync.RWMutex{},
+					metric: map[string]map[string]Type{tenant.Default: data},
 					log:    newLogger(),
 				},
 				storeRestore:  false,
@@ -323,3 +333,67 @@ func TestFileStore_Updates(t *testing.T) {
 		log.Err(err).Msg("os.Remove")
 	}
 }
+
+func TestStoreMemory_TenantIsolation(t *testing.T) {
+	s := StoreMemory{
+		mu:     &sync.RWMutex{},
+		metric: make(map[string]map[string]Type),
+		log:    newLogger(),
+	}
+
+	gauge := 1.0
+	ctxA := tenant.NewContext(context.Background(), "team-a")
+	ctxB := tenant.NewContext(context.Background(), "team-b")
+
+	s.Update(ctxA, "test", map[string]Type{"test": {Gauge: &gauge}})
+
+	got, err := s.GetOne(ctxA, "test")
+	if err != nil {
+		t.Fatalf("GetOne() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, Type{Gauge: &gauge}) {
+		t.Errorf("GetOne() got = %v, want %v", got, Type{Gauge: &gauge})
+	}
+
+	if _, err = s.GetOne(ctxB, "test"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOne() error = %v, want %v", err, ErrNotFound)
+	}
+
+	data, err := s.Get(ctxB)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Get() got = %v, want empty", data)
+	}
+}
+
+func TestStoreMemory_ConcurrentTenants(t *testing.T) {
+	s := New(newLogger(), config.Config{})
+
+	// чтения и записи новых тенантов не должны конкурировать за карту тенантов.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		ctx := tenant.NewContext(context.Background(), fmt.Sprintf("team-%d", i))
+		go func() {
+			defer wg.Done()
+			_, _ = s.Get(ctx)
+			_, _ = s.GetOne(ctx, "test")
+		}()
+		go func() {
+			defer wg.Done()
+			gauge := 1.0
+			s.Update(ctx, "test", map[string]Type{"test": {Gauge: &gauge}})
+		}()
+	}
+	wg.Wait()
+
+	got, err := s.GetOne(tenant.NewContext(context.Background(), "team-7"), "test")
+	if err != nil {
+		t.Fatalf("GetOne() error = %v", err)
+	}
+	if *got.Gauge != 1.0 {
+		t.Errorf("GetOne() got = %v, want 1", *got.Gauge)
+	}
+}
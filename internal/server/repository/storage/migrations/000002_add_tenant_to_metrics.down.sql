@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+DELETE FROM tbl_metrics WHERE tenant <> '';
+ALTER TABLE tbl_metrics DROP CONSTRAINT tbl_metrics_pkey;
+ALTER TABLE tbl_metrics DROP COLUMN tenant;
+ALTER TABLE tbl_metrics ADD PRIMARY KEY (id);
+
+COMMIT;
\ No newline at end of file
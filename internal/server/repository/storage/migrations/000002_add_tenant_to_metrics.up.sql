@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+   ALTER TABLE tbl_metrics ADD COLUMN tenant text not null default '';
+   ALTER TABLE tbl_metrics DROP CONSTRAINT tbl_metrics_pkey;
+   ALTER TABLE tbl_metrics ADD PRIMARY KEY (tenant, id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+   ALTER TABLE tbl_metrics DROP COLUMN tags;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+   ALTER TABLE tbl_metrics ADD COLUMN tags jsonb not null default '{}';
+
+COMMIT;
\ No newline at end of file
@@ -9,6 +9,7 @@ import (
 
 	"github.com/1Asi1/metric-track.git/internal/server/models"
 	"github.com/1Asi1/metric-track.git/internal/server/repository/memory"
+	"github.com/1Asi1/metric-track.git/internal/server/tenant"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -115,11 +116,13 @@ func (s *Store) Get(ctx context.Context) (map[string]memory.Type, error) {
 	SELECT
 	    id,
 	    gauge,
-		counter
+		counter,
+		tags
 	FROM tbl_metrics
+	WHERE tenant = $1
 `
 	var models []models.Metric
-	if err := s.db.SelectContext(ctx, &models, query); err != nil {
+	if err := s.db.SelectContext(ctx, &models, query, tenant.FromContext(ctx)); err != nil {
 		return nil, fmt.Errorf("Get %w;", err)
 	}
 
@@ -129,6 +132,7 @@ func (s *Store) Get(ctx context.Context) (map[string]memory.Type, error) {
 			result[v.ID] = memory.Type{
 				Gauge:   v.Gauge,
 				Counter: v.Counter,
+				Tags:    v.Tags,
 			}
 		}
 	}
@@ -139,35 +143,43 @@ func (s *Store) GetOne(ctx context.Context, name string) (memory.Type, error) {
 	query := `
 	SELECT
 	    gauge,
-		counter
+		counter,
+		tags
 	FROM tbl_metrics
-	WHERE id = $1
+	WHERE tenant = $1 AND id = $2
 `
-	var model memory.Type
-	err := s.db.GetContext(ctx, &model, query, name)
+	var model models.Metric
+	err := s.db.GetContext(ctx, &model, query, tenant.FromContext(ctx), name)
 	if err != nil {
 		return memory.Type{}, fmt.Errorf("GetOne: %w", err)
 	}
 
-	return model, nil
+	return memory.Type{
+		Gauge:   model.Gauge,
+		Counter: model.Counter,
+		Tags:    model.Tags,
+	}, nil
 }
 
 func (s *Store) Update(ctx context.Context, name string, data map[string]memory.Type) {
 	l := s.log.With().Str("postgres", "Update").Logger()
 
 	model := models.Metric{
+		Tenant:  tenant.FromContext(ctx),
 		ID:      name,
 		Gauge:   data[name].Gauge,
 		Counter: data[name].Counter,
+		Tags:    data[name].Tags,
 	}
 
 	query := `
-		INSERT INTO tbl_metrics(id,gauge,counter)
-		VALUES (:id, :gauge, :counter)
-		ON CONFLICT (id) DO UPDATE
+		INSERT INTO tbl_metrics(tenant,id,gauge,counter,tags)
+		VALUES (:tenant, :id, :gauge, :counter, :tags)
+		ON CONFLICT (tenant, id) DO UPDATE
 		SET
 		    gauge = EXCLUDED.gauge,
-		    counter = EXCLUDED.counter`
+		    counter = EXCLUDED.counter,
+		    tags = EXCLUDED.tags`
 
 	result, err := s.db.NamedExecContext(ctx, query, model)
 	if err != nil {
@@ -190,18 +202,21 @@ func (s *Store) Update(ctx context.Context, name string, data map[string]memory.
 func (s *Store) Updates(ctx context.Context, req []memory.Metric) error {
 	for _, v := range req {
 		model := models.Metric{
+			Tenant:  tenant.FromContext(ctx),
 			ID:      v.Name,
 			Gauge:   v.Value,
 			Counter: v.Delta,
+			Tags:    v.Tags,
 		}
 
 		query := `
-		INSERT INTO tbl_metrics(id,gauge,counter)
-		VALUES (:id, :gauge, :counter)
-		ON CONFLICT (id) DO UPDATE
+		INSERT INTO tbl_metrics(tenant,id,gauge,counter,tags)
+		VALUES (:tenant, :id, :gauge, :counter, :tags)
+		ON CONFLICT (tenant, id) DO UPDATE
 		SET
 		    gauge = EXCLUDED.gauge,
-		    counter = EXCLUDED.counter`
+		    counter = EXCLUDED.counter,
+		    tags = EXCLUDED.tags`
 
 		result, err := s.db.NamedExecContext(ctx, query, model)
 		if err != nil {
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/1Asi1/metric-track.git/internal/server/repository/memory"
 	"github.com/rs/zerolog"
@@ -21,9 +23,11 @@ var TypeMetric = map[string]struct{}{
 }
 
 type Metrics struct {
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
-	Delta *int64   `json:"delta,omitempty"`
+	ID    string            `json:"id"`
+	MType string            `json:"type"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	Delta *int64            `json:"delta,omitempty"`
+	// Value последнее поле, MarshalJSON дописывает дробную часть в конец JSON.
 	Value *float64 `json:"value,omitempty"`
 }
 
@@ -32,6 +36,35 @@ type MetricsRequest struct {
 	MType string   `json:"type"`
 	Delta *int64   `json:"delta,omitempty"`
 	Value *float64 `json:"value,omitempty"`
+	// метки агента: host, instance и статические метки.
+	// Метрики с одинаковым ID и разными метками хранятся раздельно, а также общим рядом под ID без меток.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// SeriesID возвращает ключ хранения метрики: ID с добавленными метками в формате name{k="v"}.
+// Метки сортируются по ключу, метрика без меток хранится под своим ID.
+func SeriesID(id string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return id
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+strconv.Quote(tags[k]))
+	}
+
+	// метки метрики агента уже записаны в ID, метки хоста добавляются к ним.
+	if name, ok := strings.CutSuffix(id, "}"); ok && strings.Contains(name, "{") {
+		return name + "," + strings.Join(pairs, ",") + "}"
+	}
+
+	return id + "{" + strings.Join(pairs, ",") + "}"
 }
 
 type Store interface {
@@ -73,7 +106,7 @@ func (s Service) GetMetric(ctx context.Context) (string, error) {
 func (s Service) GetOneMetric(ctx context.Context, req MetricsRequest) (Metrics, error) {
 	l := s.log.With().Str("service", "GetOneMetric").Logger()
 
-	data, err := s.Store.GetOne(ctx, req.ID)
+	data, err := s.Store.GetOne(ctx, SeriesID(req.ID, req.Tags))
 	if err != nil {
 		l.Error().Err(err).Msgf("s.Store.GetOne metric id: %s", req.ID)
 		return Metrics{}, fmt.Errorf("%w; %w", memory.ErrNotFound, err)
@@ -87,6 +120,7 @@ func (s Service) GetOneMetric(ctx context.Context, req MetricsRequest) (Metrics,
 			MType: Gauge,
 			Value: data.Gauge,
 			Delta: nil,
+			Tags:  data.Tags,
 		}, nil
 	}
 
@@ -95,6 +129,7 @@ func (s Service) GetOneMetric(ctx context.Context, req MetricsRequest) (Metrics,
 		MType: Counter,
 		Value: nil,
 		Delta: data.Counter,
+		Tags:  data.Tags,
 	}, nil
 }
 
@@ -108,25 +143,20 @@ func (s Service) UpdateMetric(ctx context.Context, req MetricsRequest) (Metrics,
 	}
 
 	l.Debug().Msgf("data value: %+v", data)
-	value := data[req.ID]
-	if req.MType == Gauge {
-		value.Gauge = req.Value
-	} else {
-		if value.Counter != nil {
-			*value.Counter += *req.Delta
-		} else {
-			value.Counter = req.Delta
-		}
-	}
-	data[req.ID] = value
+	id := SeriesID(req.ID, req.Tags)
+	value := apply(data, req)
 
-	s.Store.Update(ctx, req.ID, data)
+	s.Store.Update(ctx, id, data)
+	if id != req.ID {
+		s.Store.Update(ctx, req.ID, data)
+	}
 
 	return Metrics{
 		ID:    req.ID,
 		MType: req.MType,
 		Value: value.Gauge,
 		Delta: value.Counter,
+		Tags:  value.Tags,
 	}, nil
 }
 
@@ -140,23 +170,13 @@ func (s Service) Ping(ctx context.Context) error {
 }
 
 func (s Service) Updates(ctx context.Context, req []MetricsRequest) error {
-	data, err := s.Store.Get(context.Background())
+	data, err := s.Store.Get(ctx)
 	if err != nil {
 		return err
 	}
 
 	for _, v := range req {
-		value := data[v.ID]
-		if v.MType == Gauge {
-			value.Gauge = v.Value
-		} else {
-			if value.Counter != nil {
-				*value.Counter += *v.Delta
-			} else {
-				value.Counter = v.Delta
-			}
-		}
-		data[v.ID] = value
+		apply(data, v)
 	}
 
 	model := make([]memory.Metric, len(data))
@@ -166,6 +186,7 @@ func (s Service) Updates(ctx context.Context, req []MetricsRequest) error {
 			Name:  k,
 			Value: v.Gauge,
 			Delta: v.Counter,
+			Tags:  v.Tags,
 		}
 		count++
 	}
@@ -178,6 +199,35 @@ func (s Service) Updates(ctx context.Context, req []MetricsRequest) error {
 	return nil
 }
 
+// apply записывает метрику req в data и возвращает значение её ряда с метками.
+// Метрика с метками также записывается под ID без меток, чтобы её можно было прочитать по имени:
+// для gauge там хранится последнее значение от любого агента, для counter - сумма по всем агентам.
+func apply(data map[string]memory.Type, req MetricsRequest) memory.Type {
+	res := applyOne(data, SeriesID(req.ID, req.Tags), req.Tags, req)
+	if len(req.Tags) != 0 {
+		applyOne(data, req.ID, nil, req)
+	}
+
+	return res
+}
+
+func applyOne(data map[string]memory.Type, id string, tags map[string]string, req MetricsRequest) memory.Type {
+	value := data[id]
+	value.Tags = tags
+	if req.MType == Gauge {
+		value.Gauge = req.Value
+	} else if req.Delta != nil {
+		delta := *req.Delta
+		if value.Counter != nil {
+			delta += *value.Counter
+		}
+		value.Counter = &delta
+	}
+	data[id] = value
+
+	return value
+}
+
 func (s Service) parseToHTML(data *map[string]memory.Type) string {
 	var insert string
 
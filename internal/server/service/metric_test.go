@@ -124,3 +124,80 @@ func TestService_Updates(t *testing.T) {
 		})
 	}
 }
+
+func TestSeriesID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		tags map[string]string
+		want string
+	}{
+		{name: "no tags", id: "Alloc", want: "Alloc"},
+		{
+			name: "tags",
+			id:   "Alloc",
+			tags: map[string]string{"instance": "a1", "host": "web-1"},
+			want: `Alloc{host="web-1",instance="a1"}`,
+		},
+		{
+			name: "metric labels",
+			id:   `DiskFree{mount="/"}`,
+			tags: map[string]string{"host": "web-1"},
+			want: `DiskFree{mount="/",host="web-1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SeriesID(tt.id, tt.tags))
+		})
+	}
+}
+
+func TestService_UpdatesTags(t *testing.T) {
+	l := newLogger()
+	st := memory.New(l, config.Config{})
+	srv := Service{Store: st, log: l}
+
+	first, second := 1.0, 2.0
+	web1 := map[string]string{"host": "web-1"}
+	web2 := map[string]string{"host": "web-2"}
+	err := srv.Updates(context.Background(), []MetricsRequest{
+		{ID: "Alloc", MType: Gauge, Value: &first, Tags: web1},
+		{ID: "Alloc", MType: Gauge, Value: &second, Tags: web2},
+	})
+	require.NoError(t, err)
+
+	got, err := srv.GetOneMetric(context.Background(), MetricsRequest{ID: "Alloc", MType: Gauge, Tags: web2})
+	require.NoError(t, err)
+	assert.Equal(t, second, *got.Value)
+	assert.Equal(t, web2, got.Tags)
+
+	got, err = srv.GetOneMetric(context.Background(), MetricsRequest{ID: "Alloc", MType: Gauge, Tags: web1})
+	require.NoError(t, err)
+	assert.Equal(t, first, *got.Value)
+	assert.Equal(t, web1, got.Tags)
+
+	// без меток читается последнее значение от любого агента.
+	got, err = srv.GetOneMetric(context.Background(), MetricsRequest{ID: "Alloc", MType: Gauge})
+	require.NoError(t, err)
+	assert.Equal(t, second, *got.Value)
+	assert.Empty(t, got.Tags)
+
+	// счётчик без меток суммирует приращения всех агентов.
+	one, two := int64(1), int64(2)
+	err = srv.Updates(context.Background(), []MetricsRequest{
+		{ID: "Requests", MType: Counter, Delta: &one, Tags: web1},
+		{ID: "Requests", MType: Counter, Delta: &two, Tags: web2},
+		{ID: "Requests", MType: Counter, Delta: &two, Tags: web1},
+	})
+	require.NoError(t, err)
+
+	got, err = srv.GetOneMetric(context.Background(), MetricsRequest{ID: "Requests", MType: Counter, Tags: web1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), *got.Delta)
+
+	got, err = srv.GetOneMetric(context.Background(), MetricsRequest{ID: "Requests", MType: Counter})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *got.Delta)
+	assert.Equal(t, int64(1), one)
+}
@@ -0,0 +1,23 @@
+package tenant
+
+import "context"
+
+// Default тенант, к которому относятся метрики без аутентификации агента.
+const Default = ""
+
+type ctxKey struct{}
+
+// NewContext возвращает копию контекста с идентификатором тенанта.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает идентификатор тенанта из контекста или Default.
+func FromContext(ctx context.Context) string {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	if !ok {
+		return Default
+	}
+
+	return id
+}
@@ -5,9 +5,13 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
-	"net"
+	"errors"
 	"strings"
 
+	"github.com/1Asi1/metric-track.git/internal/server/acl"
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
+	"github.com/1Asi1/metric-track.git/internal/server/tenant"
+	gen "github.com/1Asi1/metric-track.git/rpc/gen"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -16,24 +20,40 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-func CheckSubnetInterceptor(trustedSubnet string) grpc.UnaryServerInterceptor {
+// MethodRoles роли, необходимые для вызова методов.
+var MethodRoles = map[string]auth.Role{
+	gen.MetricGrpc_Updates_FullMethodName:     auth.RoleIngest,
+	gen.MetricGrpc_AgentConfig_FullMethodName: auth.RoleIngest,
+}
+
+func CheckSubnetInterceptor(list *acl.ACL) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !list.Enabled() {
+			return handler(ctx, req)
+		}
+
 		p, ok := peer.FromContext(ctx)
 		if !ok {
 			return nil, status.Error(codes.Internal, "failed to get peer information")
 		}
 
-		if trustedSubnet != "" {
-			agentIP := net.ParseIP(strings.TrimSpace(p.Addr.String()))
-			_, subnet, err := net.ParseCIDR(trustedSubnet)
-			if err != nil {
-				return nil, status.Error(codes.Internal, "failed to parse trusted subnet")
-			}
-			if !subnet.Contains(agentIP) {
-				return nil, status.Error(codes.PermissionDenied, "client IP is not in trusted subnet")
+		var forwardedFor, realIP string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			forwardedFor = strings.Join(md.Get("x-forwarded-for"), ",")
+			if v := md.Get("x-real-ip"); len(v) != 0 {
+				realIP = v[0]
 			}
 		}
 
+		agentIP, err := list.ClientIP(p.Addr.String(), forwardedFor, realIP)
+		if err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		if !list.Allowed(agentIP) {
+			return nil, status.Error(codes.PermissionDenied, "client IP is not in trusted subnet")
+		}
+
 		return handler(ctx, req)
 	}
 }
@@ -50,12 +70,12 @@ func HMACInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 			return handler(ctx, req)
 		}
 
-		body, err := proto.Marshal(req.(proto.Message))
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
 		if err != nil {
 			return nil, status.Error(codes.Internal, "failed to marshal request")
 		}
 
-		h1 := hmac.New(sha256.New, []byte(secretKey))
+		h1 := hmac.New(sha256.New, []byte(auth.SecretKey(ctx, secretKey)))
 		_, err = h1.Write(body)
 		if err != nil {
 			return nil, status.Error(codes.Internal, "failed to compute HMAC")
@@ -69,3 +89,53 @@ func HMACInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 		return handler(ctx, req)
 	}
 }
+
+func AuthInterceptor(creds auth.Credentials) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !creds.Enabled() {
+			return handler(ctx, req)
+		}
+
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "failed to get metadata from context")
+		}
+
+		var header string
+		if v := md.Get("authorization"); len(v) != 0 {
+			header = v[0]
+		}
+
+		cred, err := creds.Authenticate(header)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		ctx = auth.NewContext(ctx, cred)
+		ctx = tenant.NewContext(ctx, cred.Tenant)
+		return handler(ctx, req)
+	}
+}
+
+func RoleInterceptor(creds auth.Credentials) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !creds.Enabled() {
+			return handler(ctx, req)
+		}
+
+		role, ok := MethodRoles[info.FullMethod]
+		if !ok {
+			return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+		}
+
+		err := creds.Authorize(ctx, role)
+		if errors.Is(err, auth.ErrUnauthorized) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,153 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/server/acl"
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
+	"github.com/1Asi1/metric-track.git/internal/server/tenant"
+	gen "github.com/1Asi1/metric-track.git/rpc/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthAndRoleInterceptor(t *testing.T) {
+	creds := auth.NewCredentials([]auth.Credential{
+		{Token: "ingest", Tenant: "team-a", Roles: []auth.Role{auth.RoleIngest}},
+		{Token: "read", Tenant: "team-a", Roles: []auth.Role{auth.RoleRead}},
+		{Token: "admin", Tenant: "team-b", Roles: []auth.Role{auth.RoleAdmin}},
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		method     string
+		want       codes.Code
+		wantTenant string
+	}{
+		{
+			name:   "without token",
+			method: gen.MetricGrpc_Updates_FullMethodName,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "unknown token",
+			token:  "unknown",
+			method: gen.MetricGrpc_Updates_FullMethodName,
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:       "ingest updates",
+			token:      "ingest",
+			method:     gen.MetricGrpc_Updates_FullMethodName,
+			want:       codes.OK,
+			wantTenant: "team-a",
+		},
+		{
+			name:   "read updates",
+			token:  "read",
+			method: gen.MetricGrpc_Updates_FullMethodName,
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:       "admin updates",
+			token:      "admin",
+			method:     gen.MetricGrpc_Updates_FullMethodName,
+			want:       codes.OK,
+			wantTenant: "team-b",
+		},
+		{
+			name:   "unknown method",
+			token:  "admin",
+			method: "/metric_grpc.metricGrpc/Unknown",
+			want:   codes.PermissionDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.token != "" {
+				md.Set("authorization", "Bearer "+tt.token)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+
+			var gotTenant string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				gotTenant = tenant.FromContext(ctx)
+				return &gen.UpdatesResponse{}, nil
+			}
+
+			_, err := AuthInterceptor(creds)(ctx, &gen.UpdatesRequest{}, info,
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return RoleInterceptor(creds)(ctx, req, info, handler)
+				})
+
+			assert.Equal(t, tt.want, status.Code(err))
+			assert.Equal(t, tt.wantTenant, gotTenant)
+		})
+	}
+}
+
+func TestCheckSubnetInterceptor(t *testing.T) {
+	list, err := acl.New([]string{"192.168.0.0/16", "::1/128"}, []string{"192.168.1.0/24"}, []string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		addr net.Addr
+		md   metadata.MD
+		want codes.Code
+	}{
+		{
+			name: "allowed with port",
+			addr: &net.TCPAddr{IP: net.ParseIP("192.168.2.1"), Port: 50051},
+			want: codes.OK,
+		},
+		{
+			name: "allowed ipv6",
+			addr: &net.TCPAddr{IP: net.IPv6loopback, Port: 50051},
+			want: codes.OK,
+		},
+		{
+			name: "denied",
+			addr: &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 50051},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "spoofed real ip",
+			addr: &net.TCPAddr{IP: net.ParseIP("172.16.0.1"), Port: 50051},
+			md:   metadata.Pairs("x-real-ip", "192.168.2.1"),
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "trusted proxy",
+			addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 50051},
+			md:   metadata.Pairs("x-real-ip", "192.168.2.1"),
+			want: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tt.addr})
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: gen.MetricGrpc_Updates_FullMethodName}
+
+			_, err := CheckSubnetInterceptor(list)(ctx, &gen.UpdatesRequest{}, info,
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return &gen.UpdatesResponse{}, nil
+				})
+
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+}
@@ -2,19 +2,24 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/1Asi1/metric-track.git/internal/server/agentconfig"
 	"github.com/1Asi1/metric-track.git/internal/server/service"
 	proto "github.com/1Asi1/metric-track.git/rpc/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MetricGrpcService struct {
 	proto.UnsafeMetricGrpcServer
-	service service.Service
+	service     service.Service
+	agentConfig *agentconfig.Store
 }
 
-func NewMetricGrpcServer(service service.Service) *MetricGrpcService {
-	return &MetricGrpcService{service: service}
+func NewMetricGrpcServer(service service.Service, agentConfig *agentconfig.Store) *MetricGrpcService {
+	return &MetricGrpcService{service: service, agentConfig: agentConfig}
 }
 
 func (s *MetricGrpcService) Updates(ctx context.Context, req *proto.UpdatesRequest) (*proto.UpdatesResponse, error) {
@@ -25,6 +30,7 @@ func (s *MetricGrpcService) Updates(ctx context.Context, req *proto.UpdatesReque
 			MType: v.MType,
 			Delta: &v.Delta,
 			Value: &v.Value,
+			Tags:  v.Tags,
 		}
 	}
 
@@ -36,3 +42,20 @@ func (s *MetricGrpcService) Updates(ctx context.Context, req *proto.UpdatesReque
 
 	return nil, nil
 }
+
+// AgentConfig возвращает удалённую конфигурацию агента, если её версия отличается от версии агента.
+func (s *MetricGrpcService) AgentConfig(_ context.Context, req *proto.AgentConfigRequest) (*proto.AgentConfigResponse, error) {
+	data, version, err := s.agentConfig.Get()
+	if errors.Is(err, agentconfig.ErrNotConfigured) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if req.GetVersion() == version {
+		return &proto.AgentConfigResponse{Version: version, NotModified: true}, nil
+	}
+
+	return &proto.AgentConfigResponse{Version: version, Config: data}, nil
+}
@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Asi1/metric-track.git/internal/server/agentconfig"
+	"github.com/1Asi1/metric-track.git/internal/server/service"
+	gen "github.com/1Asi1/metric-track.git/rpc/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricGrpcService_AgentConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"report_interval": "5s"}`), 0600))
+
+	s := NewMetricGrpcServer(service.Service{}, agentconfig.New(path))
+
+	res, err := s.AgentConfig(context.Background(), &gen.AgentConfigRequest{})
+	require.NoError(t, err)
+	assert.False(t, res.NotModified)
+	assert.JSONEq(t, `{"report_interval":"5s"}`, string(res.Config))
+	require.NotEmpty(t, res.Version)
+
+	res, err = s.AgentConfig(context.Background(), &gen.AgentConfigRequest{Version: res.Version})
+	require.NoError(t, err)
+	assert.True(t, res.NotModified)
+	assert.Empty(t, res.Config)
+
+	_, err = NewMetricGrpcServer(service.Service{}, nil).AgentConfig(context.Background(), &gen.AgentConfigRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"github.com/1Asi1/metric-track.git/internal/server/agentconfig"
 	"github.com/1Asi1/metric-track.git/internal/server/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
@@ -10,12 +11,15 @@ type Handler struct {
 	Mux     *chi.Mux
 	Service service.Service
 	Log     zerolog.Logger
+	// удалённая конфигурация агентов, может быть nil.
+	AgentConfig *agentconfig.Store
 }
 
-func New(mux *chi.Mux, service service.Service, log zerolog.Logger) Handler {
+func New(mux *chi.Mux, service service.Service, agentConfig *agentconfig.Store, log zerolog.Logger) Handler {
 	return Handler{
-		Mux:     mux,
-		Service: service,
-		Log:     log,
+		Mux:         mux,
+		Service:     service,
+		Log:         log,
+		AgentConfig: agentConfig,
 	}
 }
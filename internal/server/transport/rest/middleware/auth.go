@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
+	"github.com/1Asi1/metric-track.git/internal/server/tenant"
+)
+
+// AuthMiddleware аутентифицирует агента по токену и привязывает запрос к его тенанту.
+func AuthMiddleware(creds auth.Credentials) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !creds.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cred, err := creds.Authenticate(r.Header.Get("Authorization"))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			ctx := auth.NewContext(r.Context(), cred)
+			ctx = tenant.NewContext(ctx, cred.Tenant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RoleMiddleware пропускает запрос, только если у агента есть указанная роль.
+func RoleMiddleware(creds auth.Credentials, role auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := creds.Authorize(r.Context(), role)
+			if errors.Is(err, auth.ErrUnauthorized) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
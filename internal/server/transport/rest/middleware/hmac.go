@@ -7,6 +7,8 @@ import (
 	"encoding/hex"
 	"io"
 	"net/http"
+
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
 )
 
 func HMACMiddleware(next http.HandlerFunc, secretKey string) http.HandlerFunc {
@@ -25,7 +27,7 @@ func HMACMiddleware(next http.HandlerFunc, secretKey string) http.HandlerFunc {
 			return
 		}
 
-		h1 := hmac.New(sha256.New, []byte(secretKey))
+		h1 := hmac.New(sha256.New, []byte(auth.SecretKey(r.Context(), secretKey)))
 		_, err = h1.Write(data)
 		if err != nil {
 			next.ServeHTTP(w, r)
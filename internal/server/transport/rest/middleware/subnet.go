@@ -1,26 +1,32 @@
 package middleware
 
 import (
-	"net"
 	"net/http"
-	"strings"
+
+	"github.com/1Asi1/metric-track.git/internal/server/acl"
 )
 
-func CheckSubnetMiddleware(next http.HandlerFunc, trustedSubnet string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if trustedSubnet != "" {
-			agentIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
-			_, subnet, err := net.ParseCIDR(trustedSubnet)
+// CheckSubnetMiddleware пропускает запрос, только если адрес клиента разрешён списком доступа.
+func CheckSubnetMiddleware(list *acl.ACL) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !list.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			agentIP, err := list.ClientIP(r.RemoteAddr, r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Real-IP"))
 			if err != nil {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				http.Error(w, err.Error(), http.StatusForbidden)
 				return
 			}
-			if !subnet.Contains(agentIP) {
+
+			if !list.Allowed(agentIP) {
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-		}
 
-		next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r)
+		})
 	}
 }
@@ -22,7 +22,7 @@ func Example_updateMetric() {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
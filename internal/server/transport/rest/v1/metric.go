@@ -14,6 +14,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/1Asi1/metric-track.git/internal/server/agentconfig"
 	"github.com/1Asi1/metric-track.git/internal/server/repository/memory"
 	"github.com/1Asi1/metric-track.git/internal/server/service"
 	"github.com/go-chi/chi/v5"
@@ -315,12 +316,14 @@ func (h V1) Updates(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		l.Error().Err(err).Msg(" x509.ParsePKCS1PrivateKey")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	encrypteData, err := rsa.DecryptPKCS1v15(rand.Reader, privateKey, body)
+	encrypteData, err := decrypt(privateKey, body)
 	if err != nil {
-		l.Error().Err(err).Msg(" rsa.DecryptPKCS1v15")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		l.Error().Err(err).Msg("decrypt")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	var req []service.MetricsRequest
@@ -345,3 +348,55 @@ func (h V1) Updates(w http.ResponseWriter, r *http.Request) {
 		l.Err(err).Msg("w.Write")
 	}
 }
+
+// AgentConfig получить удалённую конфигурацию агента.
+// Версия конфигурации передаётся в ETag, при совпадении с If-None-Match возвращается 304.
+func (h V1) AgentConfig(w http.ResponseWriter, r *http.Request) {
+	l := h.handler.Log.With().Str("v1/metric", "AgentConfig").Logger()
+
+	data, version, err := h.handler.AgentConfig.Get()
+	if err != nil {
+		if errors.Is(err, agentconfig.ErrNotConfigured) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		l.Error().Err(err).Msg("h.handler.AgentConfig.Get")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	etag := strconv.Quote(version)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(data)
+	if err != nil {
+		l.Err(err).Msg("w.Write")
+	}
+}
+
+// decrypt расшифровывает тело запроса, зашифрованное агентом блоками размером с ключ.
+func decrypt(privateKey *rsa.PrivateKey, data []byte) ([]byte, error) {
+	size := privateKey.Size()
+	if len(data)%size != 0 {
+		return nil, fmt.Errorf("encrypted data length %d is not a multiple of key size %d", len(data), size)
+	}
+
+	res := make([]byte, 0, len(data))
+	for start := 0; start < len(data); start += size {
+		block, err := rsa.DecryptPKCS1v15(rand.Reader, privateKey, data[start:start+size])
+		if err != nil {
+			return nil, fmt.Errorf("rsa.DecryptPKCS1v15: %w", err)
+		}
+		res = append(res, block...)
+	}
+
+	return res, nil
+}
@@ -2,12 +2,17 @@ package v1
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/1Asi1/metric-track.git/internal/server/agentconfig"
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
 	"github.com/1Asi1/metric-track.git/internal/server/config"
 	"github.com/1Asi1/metric-track.git/internal/server/repository/memory"
 	"github.com/1Asi1/metric-track.git/internal/server/service"
@@ -40,7 +45,7 @@ func TestV1_UpdateMetric(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -94,7 +99,7 @@ func TestV1_UpdateMetric2(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -141,7 +146,7 @@ func TestV1_GetMetric(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -180,7 +185,7 @@ func TestV1_GetOneMetric(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -227,7 +232,7 @@ func TestV1_GetOneMetric2(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -269,7 +274,7 @@ func TestV1_Ping(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -301,7 +306,7 @@ func TestV1_Updates(t *testing.T) {
 	h := rest.Handler{
 		Mux:     router,
 		Service: se}
-	New(h, "", "")
+	New(h, "", "", nil)
 
 	s := httptest.NewServer(router)
 	defer s.Close()
@@ -330,3 +335,270 @@ func TestV1_Updates(t *testing.T) {
 		})
 	}
 }
+
+func TestV1_GetOneMetricTagged(t *testing.T) {
+	l := newLogger()
+	st := memory.New(l, config.Config{})
+	se := service.New(st, l)
+
+	router := chi.NewRouter()
+	h := rest.Handler{
+		Mux:     router,
+		Service: se}
+	New(h, "", "", nil)
+
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	first, second := 1.0, 2.5
+	one, two := int64(1), int64(2)
+	web1 := map[string]string{"host": "web-1"}
+	web2 := map[string]string{"host": "web-2"}
+	err := se.Updates(context.Background(), []service.MetricsRequest{
+		{ID: "Alloc", MType: "gauge", Value: &first, Tags: web1},
+		{ID: "Alloc", MType: "gauge", Value: &second, Tags: web2},
+		{ID: "Requests", MType: "counter", Delta: &one, Tags: web1},
+		{ID: "Requests", MType: "counter", Delta: &two, Tags: web2},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "gauge", path: "/value/gauge/Alloc", want: "2.5"},
+		{name: "counter", path: "/value/counter/Requests", want: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := resty.New().R().Get(s.URL + tt.path)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, res.StatusCode())
+			assert.Equal(t, tt.want, string(res.Body()))
+		})
+	}
+
+	res, err := resty.New().R().
+		SetHeader("Content-Type", "application/json; charset=utf-8").
+		SetBody(service.MetricsRequest{ID: "Requests", MType: "counter", Tags: web1}).
+		Post(fmt.Sprintf("%s/value/", s.URL))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	assert.JSONEq(t, `{"id":"Requests","type":"counter","tags":{"host":"web-1"},"delta":1}`, string(res.Body()))
+}
+
+func TestV1_Tenants(t *testing.T) {
+	l := newLogger()
+	st := memory.New(l, config.Config{})
+	se := service.New(st, l)
+
+	router := chi.NewRouter()
+	h := rest.Handler{
+		Mux:     router,
+		Service: se}
+	New(h, "", "", auth.NewCredentials([]auth.Credential{
+		{Token: "token-a", Tenant: "team-a", Roles: []auth.Role{auth.RoleAdmin}},
+		{Token: "token-b", Tenant: "team-b", Roles: []auth.Role{auth.RoleAdmin}},
+	}))
+
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	res, err := resty.New().R().SetAuthToken("token-a").Post(fmt.Sprintf("%s/update/gauge/test/3.14", s.URL))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{
+			name:  "owner tenant",
+			token: "token-a",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "other tenant",
+			token: "token-b",
+			want:  http.StatusNotFound,
+		},
+		{
+			name:  "unknown token",
+			token: "token-c",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name: "without token",
+			want: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/value/gauge/test", s.URL)
+
+			res, err := resty.New().R().SetAuthToken(tt.token).Get(url)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, res.StatusCode())
+		})
+	}
+}
+
+func TestV1_Roles(t *testing.T) {
+	l := newLogger()
+	st := memory.New(l, config.Config{})
+	se := service.New(st, l)
+
+	router := chi.NewRouter()
+	h := rest.Handler{
+		Mux:     router,
+		Service: se}
+	New(h, "", "", auth.NewCredentials([]auth.Credential{
+		{Token: "ingest", Roles: []auth.Role{auth.RoleIngest}},
+		{Token: "read", Roles: []auth.Role{auth.RoleRead}},
+		{Token: "admin", Roles: []auth.Role{auth.RoleAdmin}},
+		{Token: "default"},
+	}))
+
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	value := 1.0
+	metric := service.MetricsRequest{ID: "test", MType: service.Gauge, Value: &value}
+
+	type route struct {
+		method string
+		path   string
+		body   any
+	}
+	routes := map[string]route{
+		"get all":       {method: http.MethodGet, path: "/"},
+		"ping":          {method: http.MethodGet, path: "/ping"},
+		"get value":     {method: http.MethodGet, path: "/value/gauge/test"},
+		"post value":    {method: http.MethodPost, path: "/value/", body: metric},
+		"update url":    {method: http.MethodPost, path: "/update/gauge/test/1"},
+		"update json":   {method: http.MethodPost, path: "/update/", body: metric},
+		"updates batch": {method: http.MethodPost, path: "/updates/"},
+	}
+
+	// маршруты, отсутствующие в want, должны пройти авторизацию.
+	tests := []struct {
+		token string
+		want  map[string]int
+	}{
+		{
+			token: "",
+			want: map[string]int{
+				"get all": http.StatusUnauthorized, "ping": http.StatusUnauthorized,
+				"get value": http.StatusUnauthorized, "post value": http.StatusUnauthorized,
+				"update url": http.StatusUnauthorized, "update json": http.StatusUnauthorized,
+				"updates batch": http.StatusUnauthorized,
+			},
+		},
+		{
+			token: "ingest",
+			want: map[string]int{
+				"get all": http.StatusForbidden, "get value": http.StatusForbidden, "post value": http.StatusForbidden,
+			},
+		},
+		{
+			token: "default",
+			want: map[string]int{
+				"get all": http.StatusForbidden, "get value": http.StatusForbidden, "post value": http.StatusForbidden,
+			},
+		},
+		{
+			token: "read",
+			want: map[string]int{
+				"update url": http.StatusForbidden, "update json": http.StatusForbidden,
+				"updates batch": http.StatusForbidden,
+			},
+		},
+		{
+			token: "admin",
+			want:  map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		for name, rt := range routes {
+			t.Run(fmt.Sprintf("%s/%s", tt.token, name), func(t *testing.T) {
+				req := resty.New().R().SetAuthToken(tt.token)
+				if rt.body != nil {
+					req.SetBody(rt.body)
+				}
+
+				res, err := req.Execute(rt.method, s.URL+rt.path)
+				require.NoError(t, err)
+
+				want, ok := tt.want[name]
+				if ok {
+					assert.Equal(t, want, res.StatusCode())
+					return
+				}
+				assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, res.StatusCode())
+			})
+		}
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	first, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, []byte(`[{"id":"a",`))
+	require.NoError(t, err)
+	second, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, []byte(`"type":"gauge"}]`))
+	require.NoError(t, err)
+
+	got, err := decrypt(key, append(first, second...))
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":"a","type":"gauge"}]`, string(got))
+
+	_, err = decrypt(key, first[:len(first)-1])
+	assert.Error(t, err)
+}
+
+func TestV1_AgentConfig(t *testing.T) {
+	l := newLogger()
+	st := memory.New(l, config.Config{})
+	se := service.New(st, l)
+
+	path := filepath.Join(t.TempDir(), "agent.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"poll_interval": "1s"}`), 0600))
+
+	router := chi.NewRouter()
+	h := rest.Handler{
+		Mux:         router,
+		Service:     se,
+		AgentConfig: agentconfig.New(path)}
+	New(h, "", "", nil)
+
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	url := fmt.Sprintf("%s/agent/config", s.URL)
+	res, err := resty.New().R().Get(url)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.JSONEq(t, `{"poll_interval":"1s"}`, string(res.Body()))
+
+	etag := res.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	res, err = resty.New().R().SetHeader("If-None-Match", etag).Get(url)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode())
+
+	// без файла конфигурации маршрут возвращает 404.
+	router = chi.NewRouter()
+	New(rest.Handler{Mux: router, Service: se}, "", "", nil)
+	empty := httptest.NewServer(router)
+	defer empty.Close()
+
+	res, err = resty.New().R().Get(fmt.Sprintf("%s/agent/config", empty.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"github.com/1Asi1/metric-track.git/internal/server/auth"
 	"github.com/1Asi1/metric-track.git/internal/server/service"
 	"github.com/1Asi1/metric-track.git/internal/server/transport/rest"
 	"github.com/1Asi1/metric-track.git/internal/server/transport/rest/middleware"
@@ -12,14 +13,16 @@ type V1 struct {
 	service   service.Service
 	secretKey string
 	cryptoKey string
+	creds     auth.Credentials
 }
 
-func New(h rest.Handler, secretKey, cryptoKey string) {
+func New(h rest.Handler, secretKey, cryptoKey string, creds auth.Credentials) {
 	v1 := V1{
 		handler:   h,
 		service:   h.Service,
 		secretKey: secretKey,
 		cryptoKey: cryptoKey,
+		creds:     creds,
 	}
 
 	v1.handler.Mux.Use(middleware.GzipMiddleware)
@@ -28,12 +31,22 @@ func New(h rest.Handler, secretKey, cryptoKey string) {
 
 func (h V1) registerV1Route() {
 	h.handler.Mux.Route("/", func(r chi.Router) {
-		r.Get("/", h.GetMetric)
+		r.Use(middleware.AuthMiddleware(h.creds))
 		r.Get("/ping", h.Ping)
-		r.Get("/value/{metric}/{name}", h.GetOneMetric)
-		r.Post("/update/{metric}/{name}/{value}", middleware.HMACMiddleware(h.UpdateMetric, h.secretKey))
-		r.Post("/value/", h.GetOneMetric2)
-		r.Post("/update/", middleware.HMACMiddleware(h.UpdateMetric2, h.secretKey))
-		r.Post("/updates/", middleware.CheckSubnetMiddleware(middleware.HMACMiddleware(h.Updates, h.secretKey), ""))
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware(h.creds, auth.RoleRead))
+			r.Get("/", h.GetMetric)
+			r.Get("/value/{metric}/{name}", h.GetOneMetric)
+			r.Post("/value/", h.GetOneMetric2)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware(h.creds, auth.RoleIngest))
+			r.Post("/update/{metric}/{name}/{value}", middleware.HMACMiddleware(h.UpdateMetric, h.secretKey))
+			r.Post("/update/", middleware.HMACMiddleware(h.UpdateMetric2, h.secretKey))
+			r.Post("/updates/", middleware.HMACMiddleware(h.Updates, h.secretKey))
+			r.Get("/agent/config", h.AgentConfig)
+		})
 	})
 }
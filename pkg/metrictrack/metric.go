@@ -0,0 +1,54 @@
+package metrictrack
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Counter счётчик, приращения которого суммируются на сервере.
+type Counter struct {
+	name  string
+	delta atomic.Int64
+}
+
+// Add прибавляет delta к счётчику.
+func (c *Counter) Add(delta int64) {
+	c.delta.Add(delta)
+}
+
+// Inc увеличивает счётчик на единицу.
+func (c *Counter) Inc() {
+	c.delta.Add(1)
+}
+
+// take возвращает накопленное с прошлой отправки приращение и обнуляет его.
+func (c *Counter) take() int64 {
+	return c.delta.Swap(0)
+}
+
+// Gauge метрика, значение которой заменяет предыдущее на сервере.
+type Gauge struct {
+	name string
+	bits atomic.Uint64
+	set  atomic.Bool
+}
+
+// Set устанавливает значение метрики.
+func (g *Gauge) Set(value float64) {
+	g.bits.Store(math.Float64bits(value))
+	g.set.Store(true)
+}
+
+// Value возвращает последнее установленное значение.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// load возвращает значение и признак того, что оно было установлено.
+func (g *Gauge) load() (float64, bool) {
+	if !g.set.Load() {
+		return 0, false
+	}
+
+	return g.Value(), true
+}
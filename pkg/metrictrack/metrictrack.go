@@ -0,0 +1,262 @@
+// Package metrictrack позволяет сервису отправлять метрики на сервер metric-track
+// напрямую, без отдельного процесса агента.
+//
+// Метрики регистрируются на клиенте и отправляются батчами с тем же сжатием,
+// подписью и шифрованием, что и у агента:
+//
+//	c, err := metrictrack.New(metrictrack.Config{Addr: "localhost:8080", CryptoKey: "pbkey.pem"})
+//	if err != nil {
+//		return err
+//	}
+//	c.Start(ctx)
+//	defer c.Close(context.Background())
+//
+//	orders := c.Counter("OrdersCreated")
+//	orders.Inc()
+package metrictrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1Asi1/metric-track.git/internal/agent/config"
+	"github.com/1Asi1/metric-track.git/internal/agent/integration"
+	"github.com/1Asi1/metric-track.git/internal/agent/metric"
+	"github.com/rs/zerolog"
+)
+
+const (
+	reportInterval = 10 * time.Second
+)
+
+var (
+	ErrNoAddr = errors.New("server address is empty")
+)
+
+// Config настройки клиента.
+type Config struct {
+	// адрес HTTP сервера метрик.
+	Addr string
+	// адрес gRPC сервера метрик, нужен для протокола grpc.
+	GrpcAddr string
+	// протокол отправки: http или grpc, по умолчанию http.
+	Protocol string
+	// токен агента для авторизации на сервере.
+	Token string
+	// ключ подписи HashSHA256.
+	SecretKey string
+	// путь к публичному ключу для шифрования метрик.
+	CryptoKey string
+	// интервал периодической отправки, по умолчанию 10 секунд.
+	ReportInterval time.Duration
+	// таймаут одного запроса к серверу.
+	RequestTimeout time.Duration
+	// имя хоста в метке host, по умолчанию имя хоста из ОС.
+	Hostname string
+	// идентификатор экземпляра сервиса в метке instance.
+	InstanceID string
+	// статические метки всех метрик клиента.
+	Tags map[string]string
+	// журнал клиента, по умолчанию журналирование отключено.
+	Log *zerolog.Logger
+}
+
+// Client набор метрик сервиса, периодически отправляемых на сервер.
+type Client struct {
+	client   *integration.Client
+	interval time.Duration
+	log      zerolog.Logger
+
+	mu       sync.Mutex
+	counters map[string]*Counter
+	gauges   map[string]*Gauge
+
+	startOnce sync.Once
+	started   atomic.Bool
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.Addr == "" {
+		return nil, ErrNoAddr
+	}
+
+	log := zerolog.Nop()
+	if cfg.Log != nil {
+		log = *cfg.Log
+	}
+
+	agentCfg := config.Default()
+	agentCfg.MetricServerAddr = cfg.Addr
+	agentCfg.ServerGrpcAddr = cfg.GrpcAddr
+	agentCfg.Servers = []config.Server{{Addr: cfg.Addr, GrpcAddr: cfg.GrpcAddr}}
+	agentCfg.Token = cfg.Token
+	agentCfg.SecretKey = cfg.SecretKey
+	agentCfg.CryptoKey = cfg.CryptoKey
+	if cfg.Protocol != "" {
+		agentCfg.Protocol = cfg.Protocol
+	}
+	if cfg.RequestTimeout != 0 {
+		agentCfg.RequestTimeout = cfg.RequestTimeout
+	}
+	agentCfg.Hostname = cfg.Hostname
+	if agentCfg.Hostname == "" {
+		agentCfg.Hostname, _ = os.Hostname()
+	}
+	agentCfg.InstanceID = cfg.InstanceID
+	agentCfg.Tags = cfg.Tags
+
+	interval := cfg.ReportInterval
+	if interval <= 0 {
+		interval = reportInterval
+	}
+
+	client, err := integration.NewClient(agentCfg, nil, nil, log)
+	if err != nil {
+		return nil, fmt.Errorf("integration.NewClient: %w", err)
+	}
+
+	return &Client{
+		client:   client,
+		interval: interval,
+		log:      log,
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*Gauge),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Counter возвращает счётчик name, повторный вызов с тем же именем возвращает тот же счётчик.
+func (c *Client) Counter(name string) *Counter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.counters[name]
+	if !ok {
+		v = &Counter{name: name}
+		c.counters[name] = v
+	}
+
+	return v
+}
+
+// Gauge возвращает gauge-метрику name, повторный вызов с тем же именем возвращает ту же метрику.
+func (c *Client) Gauge(name string) *Gauge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.gauges[name]
+	if !ok {
+		v = &Gauge{name: name}
+		c.gauges[name] = v
+	}
+
+	return v
+}
+
+// Start запускает периодическую отправку метрик до отмены ctx или вызова Close.
+func (c *Client) Start(ctx context.Context) {
+	c.startOnce.Do(func() {
+		c.started.Store(true)
+		go c.run(ctx)
+	})
+}
+
+func (c *Client) run(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				c.log.Err(err).Msg("c.Flush")
+			}
+		}
+	}
+}
+
+// Flush отправляет текущие значения метрик.
+// Приращения счётчиков из недоставленных батчей сохраняются до следующей отправки.
+func (c *Client) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	counters := make([]*Counter, 0, len(c.counters))
+	for _, v := range c.counters {
+		counters = append(counters, v)
+	}
+	gauges := make([]*Gauge, 0, len(c.gauges))
+	for _, v := range c.gauges {
+		gauges = append(gauges, v)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(counters, func(i, j int) bool { return counters[i].name < counters[j].name })
+	sort.Slice(gauges, func(i, j int) bool { return gauges[i].name < gauges[j].name })
+
+	var metrics []metric.Metric
+	taken := make(map[string]*Counter, len(counters))
+	for _, v := range counters {
+		delta := v.take()
+		if delta == 0 {
+			continue
+		}
+		taken[v.name] = v
+		metrics = append(metrics, metric.NewCounter(v.name, delta))
+	}
+	for _, v := range gauges {
+		value, ok := v.load()
+		if !ok {
+			continue
+		}
+		metrics = append(metrics, metric.NewGauge(v.name, value))
+	}
+
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	if err := c.client.Report(ctx, metrics); err != nil {
+		// приращения из доставленных батчей уже учтены сервером, возвращаются только недоставленные.
+		var reportErr *integration.ReportError
+		if errors.As(err, &reportErr) {
+			for _, v := range reportErr.Failed {
+				if counter, ok := taken[v.ID]; ok && v.Delta != nil {
+					counter.Add(*v.Delta)
+				}
+			}
+		}
+		return fmt.Errorf("c.client.Report: %w", err)
+	}
+
+	return nil
+}
+
+// Close останавливает периодическую отправку, отправляет оставшиеся значения и закрывает соединения.
+func (c *Client) Close(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	if c.started.Load() {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return errors.Join(c.Flush(ctx), c.client.Close())
+}
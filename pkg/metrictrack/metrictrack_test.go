@@ -0,0 +1,214 @@
+package metrictrack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	ID    string   `json:"id"`
+	MType string   `json:"type"`
+	Delta *int64   `json:"delta"`
+	Value *float64 `json:"value"`
+}
+
+type testServer struct {
+	*httptest.Server
+	status atomic.Int64
+	// номер запроса, на который сервер ответит 400, 0 если такого нет.
+	failRequest atomic.Int64
+	requests    atomic.Int64
+
+	mu      sync.Mutex
+	batches [][]received
+}
+
+// newTestServer поднимает сервер, расшифровывающий батчи закрытым ключом, и возвращает путь к открытому ключу.
+func newTestServer(t *testing.T) (*testServer, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "pbkey.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	ts := &testServer{}
+	ts.status.Store(http.StatusOK)
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := int(ts.status.Load())
+		if ts.requests.Add(1) == ts.failRequest.Load() {
+			status = http.StatusBadRequest
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		encrypted, err := io.ReadAll(gz)
+		require.NoError(t, err)
+
+		var plain bytes.Buffer
+		for i := 0; i < len(encrypted); i += key.Size() {
+			block, err := rsa.DecryptPKCS1v15(rand.Reader, key, encrypted[i:i+key.Size()])
+			require.NoError(t, err)
+			plain.Write(block)
+		}
+
+		var batch []received
+		require.NoError(t, json.Unmarshal(plain.Bytes(), &batch))
+
+		ts.mu.Lock()
+		ts.batches = append(ts.batches, batch)
+		ts.mu.Unlock()
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, path
+}
+
+func (ts *testServer) received() map[string]received {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	res := make(map[string]received)
+	for _, batch := range ts.batches {
+		for _, v := range batch {
+			res[v.ID] = v
+		}
+	}
+	ts.batches = nil
+
+	return res
+}
+
+func newTestClient(t *testing.T, ts *testServer, key string) *Client {
+	t.Helper()
+
+	c, err := New(Config{
+		Addr:      strings.TrimPrefix(ts.URL, "http://"),
+		CryptoKey: key,
+	})
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{name: "no address", cfg: Config{}, wantErr: ErrNoAddr},
+		{name: "missing key", cfg: Config{Addr: "localhost:8080", CryptoKey: "missing.pem"}},
+		{name: "grpc without address", cfg: Config{Addr: "localhost:8080", Protocol: "grpc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.cfg)
+			assert.Error(t, err)
+			assert.Nil(t, c)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Flush(t *testing.T) {
+	ts, key := newTestServer(t)
+	c := newTestClient(t, ts, key)
+
+	orders := c.Counter("OrdersCreated")
+	assert.Same(t, orders, c.Counter("OrdersCreated"))
+	orders.Inc()
+	orders.Add(4)
+
+	queue := c.Gauge("QueueDepth")
+	queue.Set(12.5)
+	c.Gauge("NeverSet")
+
+	require.NoError(t, c.Flush(context.Background()))
+
+	got := ts.received()
+	require.Len(t, got, 2)
+	assert.Equal(t, "counter", got["OrdersCreated"].MType)
+	assert.Equal(t, int64(5), *got["OrdersCreated"].Delta)
+	assert.Equal(t, "gauge", got["QueueDepth"].MType)
+	assert.Equal(t, 12.5, *got["QueueDepth"].Value)
+
+	// отправленное приращение не отправляется повторно, gauge отправляется всегда.
+	require.NoError(t, c.Flush(context.Background()))
+	got = ts.received()
+	assert.NotContains(t, got, "OrdersCreated")
+	assert.Contains(t, got, "QueueDepth")
+}
+
+func TestClient_FlushFailureKeepsCounters(t *testing.T) {
+	ts, key := newTestServer(t)
+	c := newTestClient(t, ts, key)
+
+	c.Counter("OrdersCreated").Add(3)
+
+	ts.status.Store(http.StatusBadRequest)
+	require.Error(t, c.Flush(context.Background()))
+
+	c.Counter("OrdersCreated").Add(2)
+
+	ts.status.Store(http.StatusOK)
+	require.NoError(t, c.Flush(context.Background()))
+	assert.Equal(t, int64(5), *ts.received()["OrdersCreated"].Delta)
+}
+
+func TestClient_FlushPartialFailure(t *testing.T) {
+	ts, key := newTestServer(t)
+	c := newTestClient(t, ts, key)
+
+	// 150 счётчиков отправляются двумя батчами, второй сервер отклоняет.
+	for i := 0; i < 150; i++ {
+		c.Counter(fmt.Sprintf("Counter%03d", i)).Inc()
+	}
+	ts.failRequest.Store(2)
+	require.Error(t, c.Flush(context.Background()))
+	assert.Len(t, ts.received(), 100)
+
+	require.NoError(t, c.Flush(context.Background()))
+	got := ts.received()
+	assert.Len(t, got, 50)
+	assert.NotContains(t, got, "Counter000")
+	assert.Equal(t, int64(1), *got["Counter149"].Delta)
+}
+
+func TestClient_Close(t *testing.T) {
+	ts, key := newTestServer(t)
+	c := newTestClient(t, ts, key)
+
+	c.Start(context.Background())
+	c.Counter("OrdersCreated").Inc()
+
+	require.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, int64(1), *ts.received()["OrdersCreated"].Delta)
+}
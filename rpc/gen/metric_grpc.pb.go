@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	MetricGrpc_Updates_FullMethodName = "/metric_grpc.metricGrpc/Updates"
+	MetricGrpc_Updates_FullMethodName     = "/metric_grpc.metricGrpc/Updates"
+	MetricGrpc_AgentConfig_FullMethodName = "/metric_grpc.metricGrpc/AgentConfig"
 )
 
 // MetricGrpcClient is the client API for MetricGrpc service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MetricGrpcClient interface {
 	Updates(ctx context.Context, in *UpdatesRequest, opts ...grpc.CallOption) (*UpdatesResponse, error)
+	AgentConfig(ctx context.Context, in *AgentConfigRequest, opts ...grpc.CallOption) (*AgentConfigResponse, error)
 }
 
 type metricGrpcClient struct {
@@ -46,11 +48,21 @@ func (c *metricGrpcClient) Updates(ctx context.Context, in *UpdatesRequest, opts
 	return out, nil
 }
 
+func (c *metricGrpcClient) AgentConfig(ctx context.Context, in *AgentConfigRequest, opts ...grpc.CallOption) (*AgentConfigResponse, error) {
+	out := new(AgentConfigResponse)
+	err := c.cc.Invoke(ctx, MetricGrpc_AgentConfig_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetricGrpcServer is the server API for MetricGrpc service.
 // All implementations must embed UnimplementedMetricGrpcServer
 // for forward compatibility
 type MetricGrpcServer interface {
 	Updates(context.Context, *UpdatesRequest) (*UpdatesResponse, error)
+	AgentConfig(context.Context, *AgentConfigRequest) (*AgentConfigResponse, error)
 	mustEmbedUnimplementedMetricGrpcServer()
 }
 
@@ -61,6 +73,9 @@ type UnimplementedMetricGrpcServer struct {
 func (UnimplementedMetricGrpcServer) Updates(context.Context, *UpdatesRequest) (*UpdatesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Updates not implemented")
 }
+func (UnimplementedMetricGrpcServer) AgentConfig(context.Context, *AgentConfigRequest) (*AgentConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AgentConfig not implemented")
+}
 func (UnimplementedMetricGrpcServer) mustEmbedUnimplementedMetricGrpcServer() {}
 
 // UnsafeMetricGrpcServer may be embedded to opt out of forward compatibility for this service.
@@ -92,6 +107,24 @@ func _MetricGrpc_Updates_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetricGrpc_AgentConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AgentConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricGrpcServer).AgentConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricGrpc_AgentConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricGrpcServer).AgentConfig(ctx, req.(*AgentConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetricGrpc_ServiceDesc is the grpc.ServiceDesc for MetricGrpc service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -103,6 +136,10 @@ var MetricGrpc_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Updates",
 			Handler:    _MetricGrpc_Updates_Handler,
 		},
+		{
+			MethodName: "AgentConfig",
+			Handler:    _MetricGrpc_AgentConfig_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "metric.proto",
@@ -36,6 +36,26 @@ func (m *MockMetricGrpcClient) EXPECT() *MockMetricGrpcClientMockRecorder {
 	return m.recorder
 }
 
+// AgentConfig mocks base method.
+func (m *MockMetricGrpcClient) AgentConfig(ctx context.Context, in *gen.AgentConfigRequest, opts ...grpc.CallOption) (*gen.AgentConfigResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AgentConfig", varargs...)
+	ret0, _ := ret[0].(*gen.AgentConfigResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AgentConfig indicates an expected call of AgentConfig.
+func (mr *MockMetricGrpcClientMockRecorder) AgentConfig(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AgentConfig", reflect.TypeOf((*MockMetricGrpcClient)(nil).AgentConfig), varargs...)
+}
+
 // Updates mocks base method.
 func (m *MockMetricGrpcClient) Updates(ctx context.Context, in *gen.UpdatesRequest, opts ...grpc.CallOption) (*gen.UpdatesResponse, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +99,21 @@ func (m *MockMetricGrpcServer) EXPECT() *MockMetricGrpcServerMockRecorder {
 	return m.recorder
 }
 
+// AgentConfig mocks base method.
+func (m *MockMetricGrpcServer) AgentConfig(arg0 context.Context, arg1 *gen.AgentConfigRequest) (*gen.AgentConfigResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AgentConfig", arg0, arg1)
+	ret0, _ := ret[0].(*gen.AgentConfigResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AgentConfig indicates an expected call of AgentConfig.
+func (mr *MockMetricGrpcServerMockRecorder) AgentConfig(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AgentConfig", reflect.TypeOf((*MockMetricGrpcServer)(nil).AgentConfig), arg0, arg1)
+}
+
 // Updates mocks base method.
 func (m *MockMetricGrpcServer) Updates(arg0 context.Context, arg1 *gen.UpdatesRequest) (*gen.UpdatesResponse, error) {
 	m.ctrl.T.Helper()